@@ -19,6 +19,8 @@ type Iter[T any] struct {
 	curTemp  T
 	eleSize  uintptr
 	readOnly bool
+	track    bool
+	tick     uint32
 }
 
 func EmptyIter[T any]() Iterator[T] {
@@ -35,11 +37,13 @@ func (i *Iter[T]) End() bool {
 func (i *Iter[T]) Begin() *T {
 	if i.len != 0 {
 		i.offset = 0
+		i.pend = 0
 		if i.readOnly {
 			i.curTemp = i.data[0]
 			i.cur = &i.curTemp
 		} else {
 			i.cur = &(i.data[0])
+			i.markChanged()
 		}
 	}
 	return i.cur
@@ -60,9 +64,59 @@ func (i *Iter[T]) Next() *T {
 		} else {
 			//i.cur = &(i.data[i.offset])
 			i.cur = (*T)(unsafe.Add(i.head, i.pend))
+			i.markChanged()
 		}
 	} else {
 		i.cur = nil
 	}
 	return i.cur
 }
+
+// 组件迭代器写访问时记录修改tick
+func (i *Iter[T]) trackChanged(tick uint32) {
+	i.track = true
+	i.tick = tick
+}
+
+func (i *Iter[T]) markChanged() {
+	if i.track {
+		markChanged(unsafe.Pointer(i.cur), i.tick)
+	}
+}
+
+// 仅遍历since之后被写访问过的组件
+type changedIter[T any] struct {
+	Iter[T]
+	since uint32
+}
+
+func (i *changedIter[T]) Begin() *T {
+	i.offset = 0
+	i.pend = 0
+	return i.seek()
+}
+
+func (i *changedIter[T]) Next() *T {
+	i.offset++
+	i.pend += i.eleSize
+	return i.seek()
+}
+
+func (i *changedIter[T]) seek() *T {
+	for ; !i.End(); i.offset, i.pend = i.offset+1, i.pend+i.eleSize {
+		p := unsafe.Add(i.head, i.pend)
+		if !tickBefore(i.since, (*EmptyComponent)(p).changed) {
+			continue
+		}
+		if i.readOnly {
+			i.curTemp = *(*T)(p)
+			i.cur = &i.curTemp
+		} else {
+			i.cur = (*T)(p)
+			i.markChanged()
+		}
+		return i.cur
+	}
+	i.cur = nil
+	return i.cur
+}
@@ -38,6 +38,16 @@ type EmptyComponent struct {
 	Component[EmptyComponent]
 }
 
+// OnAddReceiver 组件实现该接口后，组件被添加到实体后在主线程中回调，接收者为组件容器中存储的组件，回调中的修改会保留
+type OnAddReceiver interface {
+	OnAdd(world IWorld, entity Entity)
+}
+
+// OnRemoveReceiver 组件实现该接口后，组件从实体移除（包括实体销毁）后在主线程中回调，接收者为被移除组件的副本
+type OnRemoveReceiver interface {
+	OnRemove(world IWorld, entity Entity)
+}
+
 type IComponent interface {
 	Owner() Entity
 	Type() reflect.Type
@@ -46,12 +56,15 @@ type IComponent interface {
 	setState(state ComponentState)
 	setIntType(typ uint16)
 	setSeq(seq uint32)
+	setAddedTick(tick uint32)
 	getState() ComponentState
 	getIntType() uint16
 	getComponentType() ComponentType
 	getPermission() ComponentPermission
 	check(initializer SystemInitConstraint)
 	getSeq() uint32
+	getAddedTick() uint32
+	getChangedTick() uint32
 	newCollection(meta *ComponentMetaInfo) IComponentSet
 	addToCollection(ct ComponentType, p unsafe.Pointer)
 	deleteFromCollection(collection interface{})
@@ -126,11 +139,13 @@ func (c componentIdentification) __ComponentIdentification() {}
 
 type Component[T ComponentObject] struct {
 	componentIdentification
-	st    uint8
-	o1    uint8
-	it    uint16
-	seq   uint32
-	owner Entity
+	st      uint8
+	o1      uint8
+	it      uint16
+	seq     uint32
+	owner   Entity
+	added   uint32
+	changed uint32
 }
 
 func (c *Component[T]) getComponentType() ComponentType {
@@ -210,6 +225,20 @@ func (c *Component[T]) getSeq() uint32 {
 	return c.seq
 }
 
+// 添加组件时同时记录添加和修改的tick
+func (c *Component[T]) setAddedTick(tick uint32) {
+	c.added = tick
+	c.changed = tick
+}
+
+func (c *Component[T]) getAddedTick() uint32 {
+	return c.added
+}
+
+func (c *Component[T]) getChangedTick() uint32 {
+	return c.changed
+}
+
 func (c *Component[T]) invalidate() {
 	c.setState(ComponentStateInvalid)
 }
@@ -2,6 +2,7 @@ package ecs
 
 import (
 	"reflect"
+	"sort"
 	"sync"
 	"unsafe"
 )
@@ -19,6 +20,8 @@ type IComponentCollection interface {
 	operate(op CollectionOperate, entity Entity, component IComponent)
 	deleteOperate(op CollectionOperate, entity Entity, it uint16)
 	getTempTasks() []func()
+	discardTempTasks()
+	callHooks()
 	clearDisposable()
 	getComponentSet(typ reflect.Type) IComponentSet
 	getComponentSetByIntType(typ uint16) IComponentSet
@@ -32,6 +35,13 @@ type ComponentCollection struct {
 	bucket      int64
 	locks       []sync.RWMutex
 	opLog       []map[reflect.Type]*opTaskList
+	hooks       []*[]componentHook
+}
+
+type componentHook struct {
+	com    IComponent
+	entity Entity
+	add    bool
 }
 
 func NewComponentCollection(world *ecsWorld, k int) *ComponentCollection {
@@ -134,7 +144,7 @@ func (c *ComponentCollection) clearDisposable() {
 		}
 		set := c.collections.Get(meta.it)
 		if set == nil {
-			return
+			continue
 		}
 		(*set).Range(func(com IComponent) bool {
 			info, ok := c.world.entities.GetEntityInfo(com.Owner())
@@ -154,7 +164,7 @@ func (c *ComponentCollection) clearFree() {
 		meta := c.world.getComponentMetaInfoByType(r)
 		set := c.collections.Get(meta.it)
 		if set == nil {
-			return
+			continue
 		}
 		(*set).Clear()
 	}
@@ -180,65 +190,104 @@ func (c *ComponentCollection) getTempTasks() []func() {
 		c.locks[i].RUnlock()
 	}
 
+	// 按组件类型id排序，任务和生命周期回调的顺序与map的遍历顺序无关
+	metas := make([]*ComponentMetaInfo, 0, len(combination))
+	for typ := range combination {
+		metas = append(metas, c.world.getComponentMetaInfoByType(typ))
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].it < metas[j].it
+	})
+
+	// 实体的compound在主线程中更新，需在opExecute回收任务前完成
+	for _, meta := range metas {
+		list := combination[meta.typ]
+		if meta.componentType&ComponentTypeFreeMask > 0 {
+			continue
+		}
+		for task := list.head; task != nil; task = task.next {
+			info, ok := c.world.getEntityInfo(task.target)
+			if !ok {
+				continue
+			}
+			switch task.op {
+			case CollectionOperateAdd:
+				info.addToCompound(meta.it)
+			case CollectionOperateDelete:
+				info.removeFromCompound(meta.it)
+			}
+		}
+	}
+
 	var tasks []func()
-	for typ, list := range combination {
-		taskList := list
+	for _, meta := range metas {
+		taskList := combination[meta.typ]
 		if taskList.Len() == 0 {
 			continue
 		}
-		meta := c.world.getComponentMetaInfoByType(typ)
 		setp := c.collections.Get(meta.it)
 		if setp == nil {
 			c.checkSet(taskList.head.com)
 			setp = c.collections.Get(meta.it)
 		}
 
+		var hooks *[]componentHook
+		if meta.onAdd || meta.onRemove {
+			// 单独分配，c.hooks扩容时任务中持有的指针仍然有效
+			hooks = &[]componentHook{}
+			c.hooks = append(c.hooks, hooks)
+		}
+
 		fn := func() {
-			c.opExecute(taskList, *setp)
+			c.opExecute(taskList, *setp, hooks)
 		}
 		tasks = append(tasks, fn)
 	}
 
-	fn := func() {
-		for typ, list := range combination {
-			meta := c.world.getComponentMetaInfoByType(typ)
-			for task := list.head; task != nil; task = task.next {
-				if task.op == CollectionOperateDelete {
-					continue
-				}
-				info, ok := c.world.getEntityInfo(task.target)
-				if ok {
-					switch task.op {
-					case CollectionOperateAdd:
-						switch task.com.getComponentType() {
-						case ComponentTypeNormal, ComponentTypeDisposable:
-							info.addToCompound(meta.it)
-						}
-					case CollectionOperateDelete:
-						switch task.com.getComponentType() {
-						case ComponentTypeNormal, ComponentTypeDisposable:
-							info.removeFromCompound(meta.it)
-						}
-					}
-				}
+	return tasks
+}
+
+// 丢弃尚未执行的组件操作，用于回滚
+func (c *ComponentCollection) discardTempTasks() {
+	for i := 0; i < len(c.opLog); i++ {
+		c.locks[i].Lock()
+		for _, list := range c.opLog[i] {
+			next := list.head
+			for next != nil {
+				task := next
+				next = next.next
+				opTaskPool.Put(task)
 			}
+			list.Reset()
 		}
+		c.locks[i].Unlock()
 	}
-	tasks = append(tasks, fn)
-	return tasks
 }
 
-func (c *ComponentCollection) opExecute(taskList *opTaskList, collection IComponentSet) {
+func (c *ComponentCollection) opExecute(taskList *opTaskList, collection IComponentSet, hooks *[]componentHook) {
 	meta := collection.GetElementMeta()
+	tick := c.world.currentTick()
+	isFree := meta.componentType&ComponentTypeFreeMask > 0
 	for task := taskList.head; task != nil; task = task.next {
 		switch task.op {
 		case CollectionOperateAdd:
+			if meta.onAdd && !isFree && collection.getPointerByEntity(task.target) != nil {
+				continue
+			}
 			task.com.setIntType(meta.it)
 			task.com.setOwner(task.target)
+			task.com.setAddedTick(tick)
 			task.com.addToCollection(task.com.getComponentType(), collection.pointer())
+			if meta.onAdd {
+				*hooks = append(*hooks, componentHook{com: task.com, entity: task.target, add: true})
+			}
 		case CollectionOperateDelete:
-			if meta.componentType&ComponentTypeFreeMask == 0 {
-				collection.Remove(task.target)
+			if isFree {
+				continue
+			}
+			removed := collection.removeAndRecord(task.target, tick)
+			if removed != nil && meta.onRemove {
+				*hooks = append(*hooks, componentHook{com: removed, entity: task.target})
 			}
 		case CollectionOperateDeleteAll:
 			collection.Clear()
@@ -253,13 +302,37 @@ func (c *ComponentCollection) opExecute(taskList *opTaskList, collection ICompon
 	taskList.Reset()
 }
 
+// 在主线程中执行本次同步中收集的组件生命周期回调
+func (c *ComponentCollection) callHooks() {
+	hooks := c.hooks
+	c.hooks = nil
+	for _, list := range hooks {
+		for _, hook := range *list {
+			if hook.add {
+				// 在容器中存储的组件上回调，回调中的修改得以保留；同一同步点中已被移除的组件（以及Free组件）使用传入的组件
+				com := hook.com
+				if set := c.getComponentSetByIntType(com.getIntType()); set != nil && set.getPointerByEntity(hook.entity) != nil {
+					com = set.GetComponent(hook.entity)
+				}
+				com.(OnAddReceiver).OnAdd(c.world, hook.entity)
+			} else {
+				hook.com.(OnRemoveReceiver).OnRemove(c.world, hook.entity)
+			}
+		}
+	}
+}
+
 func (c *ComponentCollection) getComponentSet(typ reflect.Type) IComponentSet {
 	meta := c.world.getComponentMetaInfoByType(typ)
-	return *(c.collections.Get(meta.it))
+	return c.getComponentSetByIntType(meta.it)
 }
 
 func (c *ComponentCollection) getComponentSetByIntType(it uint16) IComponentSet {
-	return *(c.collections.Get(it))
+	set := c.collections.Get(it)
+	if set == nil {
+		return nil
+	}
+	return *set
 }
 
 func (c *ComponentCollection) getCollections() *SparseArray[uint16, IComponentSet] {
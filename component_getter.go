@@ -55,6 +55,7 @@ func (g *GetterCache) Get(key reflect.Type) unsafe.Pointer {
 type ComponentGetter[T ComponentObject] struct {
 	permission ComponentPermission
 	set        *ComponentSet[T]
+	world      *ecsWorld
 }
 
 func NewComponentGetter[T ComponentObject](sys ISystem) *ComponentGetter[T] {
@@ -71,13 +72,18 @@ func NewComponentGetter[T ComponentObject](sys ISystem) *ComponentGetter[T] {
 	}
 	getter.set = seti.(*ComponentSet[T])
 	getter.permission = r.getPermission()
+	getter.world = sys.World().base()
 	return getter
 }
 
 func (c *ComponentGetter[T]) Get(entity Entity) *T {
+	data := c.set.getByEntity(entity)
+	if data == nil {
+		return nil
+	}
 	if c.permission == ComponentReadOnly {
-		return &(*c.set.getByEntity(entity))
-	} else {
-		return c.set.getByEntity(entity)
+		return &(*data)
 	}
+	markChanged(unsafe.Pointer(data), c.world.currentTick())
+	return data
 }
@@ -14,8 +14,15 @@ type ComponentMetaInfo struct {
 	componentType ComponentType
 	o1            uint8
 	typ           reflect.Type
+	onAdd         bool
+	onRemove      bool
 }
 
+var (
+	onAddReceiverType    = reflect.TypeOf((*OnAddReceiver)(nil)).Elem()
+	onRemoveReceiverType = reflect.TypeOf((*OnRemoveReceiver)(nil)).Elem()
+)
+
 type componentMeta struct {
 	world      *ecsWorld
 	seq        uint16
@@ -43,6 +50,8 @@ func (c *componentMeta) CreateComponentMetaInfo(typ reflect.Type, ct ComponentTy
 	info.it = c.seq
 	info.componentType = ct
 	info.typ = typ
+	info.onAdd = reflect.PointerTo(typ).Implements(onAddReceiverType)
+	info.onRemove = reflect.PointerTo(typ).Implements(onRemoveReceiverType)
 
 	c.types[typ] = info.it
 	info = c.infos.Add(info.it, info)
@@ -17,16 +17,22 @@ type IComponentSet interface {
 	Sort()
 
 	getPointerByIndex(index int64) unsafe.Pointer
+	removeAndRecord(entity Entity, tick uint32) IComponent
+	rangeRemoved(since uint32, fn func(entity Entity) bool)
+	trimRemoved(since uint32)
 	changeCount() int64
 	changeReset()
 	pointer() unsafe.Pointer
 	getPointerByEntity(entity Entity) unsafe.Pointer
+	saveState(prev any) any
+	loadState(state any)
 }
 
 type ComponentSet[T ComponentObject] struct {
 	SparseArray[int32, T]
-	change int64
-	meta   *ComponentMetaInfo
+	change  int64
+	meta    *ComponentMetaInfo
+	removed []removedRecord
 }
 
 func NewComponentSet[T ComponentObject](meta *ComponentMetaInfo, initSize ...int) *ComponentSet[T] {
@@ -48,6 +54,9 @@ func (c *ComponentSet[T]) Add(element *T, entity Entity) *T {
 }
 
 func (c *ComponentSet[T]) remove(entity Entity) *T {
+	if c.getByEntity(entity) == nil {
+		return nil
+	}
 	index := entity.ToRealID().index
 	return c.SparseArray.Remove(index)
 }
@@ -60,13 +69,57 @@ func (c *ComponentSet[T]) Remove(entity Entity) {
 	c.change++
 }
 
+// 移除组件并记录移除时的tick，按tick有序追加，返回被移除组件的副本
+func (c *ComponentSet[T]) removeAndRecord(entity Entity, tick uint32) IComponent {
+	data := c.remove(entity)
+	if data == nil {
+		return nil
+	}
+	c.change++
+	c.removed = append(c.removed, removedRecord{entity: entity, tick: tick})
+	return any(data).(IComponent)
+}
+
+func (c *ComponentSet[T]) rangeRemoved(since uint32, fn func(entity Entity) bool) {
+	start := len(c.removed)
+	for start > 0 && !tickBefore(c.removed[start-1].tick, since) {
+		start--
+	}
+	for i := start; i < len(c.removed); i++ {
+		if !fn(c.removed[i].entity) {
+			break
+		}
+	}
+}
+
+func (c *ComponentSet[T]) trimRemoved(since uint32) {
+	i := 0
+	for ; i < len(c.removed) && tickBefore(c.removed[i].tick, since); i++ {
+	}
+	if i == 0 {
+		return
+	}
+	n := copy(c.removed, c.removed[i:])
+	c.removed = c.removed[:n]
+}
+
+func (c *ComponentSet[T]) Clear() {
+	c.SparseArray.Clear()
+	c.removed = nil
+}
+
 func (c *ComponentSet[T]) RemoveAndReturn(entity Entity) *T {
 	cpy := *c.remove(entity)
 	return &cpy
 }
 
+// 按索引查找后校验组件的owner，避免过期的Entity取到复用该索引的实体的组件
 func (c *ComponentSet[T]) getByEntity(entity Entity) *T {
-	return c.SparseArray.Get(entity.ToRealID().index)
+	data := c.SparseArray.Get(entity.ToRealID().index)
+	if data == nil || (*EmptyComponent)(unsafe.Pointer(data)).owner != entity {
+		return nil
+	}
+	return data
 }
 
 func (c *ComponentSet[T]) getPointerByEntity(entity Entity) unsafe.Pointer {
@@ -162,3 +215,28 @@ func NewComponentSetIterator[T ComponentObject](collection *ComponentSet[T], rea
 
 	return iter
 }
+
+type componentSetState[T ComponentObject] struct {
+	sparseArrayState[int32, T]
+	change  int64
+	removed []removedRecord
+}
+
+// 保存容器数据的副本，prev为同类型的旧副本时复用其内存
+func (c *ComponentSet[T]) saveState(prev any) any {
+	s, ok := prev.(*componentSetState[T])
+	if !ok {
+		s = &componentSetState[T]{}
+	}
+	c.SparseArray.saveState(&s.sparseArrayState)
+	s.change = c.change
+	s.removed = append(s.removed[:0], c.removed...)
+	return s
+}
+
+func (c *ComponentSet[T]) loadState(state any) {
+	s := state.(*componentSetState[T])
+	c.SparseArray.loadState(&s.sparseArrayState)
+	c.change = s.change
+	c.removed = append(c.removed[:0], s.removed...)
+}
@@ -1,6 +1,11 @@
 package ecs
 
-import "testing"
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
 
 func TestComponent_isValidComponentType(t *testing.T) {
 	type C1 struct {
@@ -80,3 +85,209 @@ func TestComponent_isValidComponentType(t *testing.T) {
 		})
 	}
 }
+
+type __component_Test_Hook_C_1 struct {
+	Component[__component_Test_Hook_C_1]
+	Field1 int
+}
+
+var __component_Test_Hook_Log []string
+
+func (c *__component_Test_Hook_C_1) OnAdd(world IWorld, entity Entity) {
+	__component_Test_Hook_Log = append(__component_Test_Hook_Log, fmt.Sprintf("add %d %d", entity, c.Field1))
+}
+
+func (c *__component_Test_Hook_C_1) OnRemove(world IWorld, entity Entity) {
+	__component_Test_Hook_Log = append(__component_Test_Hook_Log, fmt.Sprintf("remove %d %d", entity, c.Field1))
+}
+
+func TestComponent_Hooks(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterComponent[__component_Test_Hook_C_1](world)
+	world.Startup()
+
+	__component_Test_Hook_Log = nil
+	e1 := world.NewEntity()
+	e2 := world.NewEntity()
+	world.Add(e1, &__component_Test_Hook_C_1{Field1: 1})
+	world.Add(e2, &__component_Test_Hook_C_1{Field1: 2})
+	world.Add(e2, &__component_Test_Hook_C_1{Field1: 3})
+	world.Update()
+
+	world.Remove(e1, &__component_Test_Hook_C_1{})
+	world.DestroyEntity(e2)
+	world.Update()
+	world.Stop()
+
+	want := []string{
+		fmt.Sprintf("add %d 1", e1),
+		fmt.Sprintf("add %d 2", e2),
+		fmt.Sprintf("remove %d 1", e1),
+		fmt.Sprintf("remove %d 2", e2),
+	}
+	if !reflect.DeepEqual(__component_Test_Hook_Log, want) {
+		t.Errorf("hooks want %v, got %v", want, __component_Test_Hook_Log)
+	}
+}
+
+type __component_Test_Hook_C_Init struct {
+	Component[__component_Test_Hook_C_Init]
+	Field1 int
+}
+
+func (c *__component_Test_Hook_C_Init) OnAdd(world IWorld, entity Entity) {
+	c.Field1 *= 10
+}
+
+// OnAdd中对组件的修改需要保留在容器中
+func TestComponent_HookMutation(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterComponent[__component_Test_Hook_C_Init](world)
+	world.Startup()
+
+	e1 := world.NewEntity()
+	e2 := world.NewEntity()
+	world.Add(e1, &__component_Test_Hook_C_Init{Field1: 1})
+	world.Add(e2, &__component_Test_Hook_C_Init{Field1: 2})
+	world.Update()
+
+	set := world.getComponentSet(TypeOf[__component_Test_Hook_C_Init]()).(*ComponentSet[__component_Test_Hook_C_Init])
+	if c := set.Get(e1); c == nil || c.Field1 != 10 {
+		t.Errorf("mutation in OnAdd not kept: %+v", c)
+	}
+	if c := set.Get(e2); c == nil || c.Field1 != 20 {
+		t.Errorf("mutation in OnAdd not kept: %+v", c)
+	}
+	world.Stop()
+}
+
+type __component_Test_Hook_C_2 struct {
+	Component[__component_Test_Hook_C_2]
+	Field1 int
+}
+
+func (c *__component_Test_Hook_C_2) OnAdd(world IWorld, entity Entity) {
+	__component_Test_Hook_Log = append(__component_Test_Hook_Log, fmt.Sprintf("add2 %d %d", entity, c.Field1))
+}
+
+type __component_Test_Hook_C_3 struct {
+	Component[__component_Test_Hook_C_3]
+	Field1 int
+}
+
+func (c *__component_Test_Hook_C_3) OnRemove(world IWorld, entity Entity) {
+	__component_Test_Hook_Log = append(__component_Test_Hook_Log, fmt.Sprintf("remove3 %d %d", entity, c.Field1))
+}
+
+// 多种带回调的组件在同一个同步点生效时，每种组件的回调都要执行
+func TestComponent_HooksMultipleTypes(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterComponent[__component_Test_Hook_C_1](world)
+	RegisterComponent[__component_Test_Hook_C_2](world)
+	RegisterComponent[__component_Test_Hook_C_3](world)
+	world.Startup()
+
+	__component_Test_Hook_Log = nil
+	e := world.NewEntity()
+	world.Add(e, &__component_Test_Hook_C_1{Field1: 1}, &__component_Test_Hook_C_2{Field1: 2}, &__component_Test_Hook_C_3{Field1: 3})
+	world.Update()
+	world.DestroyEntity(e)
+	world.Update()
+	world.Stop()
+
+	want := []string{
+		fmt.Sprintf("add %d 1", e),
+		fmt.Sprintf("add2 %d 2", e),
+		fmt.Sprintf("remove %d 1", e),
+		fmt.Sprintf("remove3 %d 3", e),
+	}
+	sort.Strings(want)
+	got := append([]string(nil), __component_Test_Hook_Log...)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks want %v, got %v", want, got)
+	}
+}
+
+type __component_Test_Disposable_C_1 struct {
+	DisposableComponent[__component_Test_Disposable_C_1]
+}
+
+type __component_Test_Disposable_C_2 struct {
+	DisposableComponent[__component_Test_Disposable_C_2]
+}
+
+type __component_Test_Disposable_C_3 struct {
+	DisposableComponent[__component_Test_Disposable_C_3]
+}
+
+type __component_Test_Disposable_C_4 struct {
+	DisposableComponent[__component_Test_Disposable_C_4]
+	Field1 int
+}
+
+// 未创建过组件集合的一次性组件不影响其他一次性组件的清理，map遍历顺序随机，多次创建世界
+func TestComponent_ClearDisposable(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		config := NewDefaultWorldConfig()
+		config.Debug = false
+		config.MetaInfoDebugPrint = false
+		world := NewSyncWorld(config)
+		RegisterComponent[__component_Test_Disposable_C_1](world)
+		RegisterComponent[__component_Test_Disposable_C_2](world)
+		RegisterComponent[__component_Test_Disposable_C_3](world)
+		RegisterComponent[__component_Test_Disposable_C_4](world)
+		world.Startup()
+
+		e := world.NewEntity()
+		world.Add(e, &__component_Test_Disposable_C_4{Field1: 1})
+		world.Update()
+		if set := world.components.getComponentSet(TypeOf[__component_Test_Disposable_C_4]()); set == nil || set.Len() != 0 {
+			t.Fatalf("disposable component should be cleared at the end of frame")
+		}
+		world.Stop()
+	}
+}
+
+type __component_Test_Free_C_1 struct {
+	FreeComponent[__component_Test_Free_C_1]
+}
+
+type __component_Test_Free_C_2 struct {
+	FreeComponent[__component_Test_Free_C_2]
+}
+
+type __component_Test_Free_C_3 struct {
+	FreeComponent[__component_Test_Free_C_3]
+	Field1 int
+}
+
+func TestComponent_ClearFree(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		config := NewDefaultWorldConfig()
+		config.Debug = false
+		config.MetaInfoDebugPrint = false
+		world := NewSyncWorld(config)
+		RegisterComponent[__component_Test_Free_C_1](world)
+		RegisterComponent[__component_Test_Free_C_2](world)
+		RegisterComponent[__component_Test_Free_C_3](world)
+		world.Startup()
+
+		AddFreeComponent[__component_Test_Free_C_3](world, &__component_Test_Free_C_3{Field1: 1})
+		world.Update()
+		world.components.(*ComponentCollection).clearFree()
+		if set := world.components.getComponentSet(TypeOf[__component_Test_Free_C_3]()); set == nil || set.Len() != 0 {
+			t.Fatalf("free component should be cleared")
+		}
+		world.Stop()
+	}
+}
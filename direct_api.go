@@ -5,14 +5,57 @@ import (
 	"unsafe"
 )
 
-func RegisterSystem[T SystemObject, TP SystemPointer[T]](world IWorld, order ...Order) {
+func RegisterSystem[T SystemObject, TP SystemPointer[T]](world IWorld, options ...SystemOption) {
 	sys := TP(new(T))
-	if len(order) > 0 {
-		sys.setOrder(order[0])
+	for _, option := range options {
+		option.apply(sys)
 	}
 	world.registerSystem(sys)
 }
 
+// AddSystem 在运行中的世界添加系统，在下一帧开始的同步点执行Init并加入调度，当帧执行Start阶段；
+// 世界未启动时等同于RegisterSystem
+func AddSystem[T SystemObject, TP SystemPointer[T]](getter IUtilityGetter, options ...SystemOption) {
+	sys := TP(new(T))
+	for _, option := range options {
+		option.apply(sys)
+	}
+	getter.getWorld().base().systemFlow.addSystem(sys)
+}
+
+// RemoveSystem 从运行中的世界移除系统，在下一帧开始的同步点进入销毁阶段，当帧执行Destroy阶段后移除
+func RemoveSystem[T SystemObject](getter IUtilityGetter) {
+	getter.getWorld().base().systemFlow.removeSystem(TypeOf[T]())
+}
+
+// PauseSystem 暂停系统的更新阶段，在下一帧开始的同步点生效，异步世界中通过SyncWrapper调用
+func PauseSystem[T SystemObject](getter IUtilityGetter) {
+	getter.getWorld().base().systemFlow.controlSystem(TypeOf[T](), systemControlPause)
+}
+
+// ResumeSystem 恢复暂停的系统，在下一帧开始的同步点生效
+func ResumeSystem[T SystemObject](getter IUtilityGetter) {
+	getter.getWorld().base().systemFlow.controlSystem(TypeOf[T](), systemControlResume)
+}
+
+// StopSystem 停止系统，在下一帧开始的同步点生效，当帧执行Destroy阶段后系统不再执行，但仍保留在世界中
+func StopSystem[T SystemObject](getter IUtilityGetter) {
+	getter.getWorld().base().systemFlow.controlSystem(TypeOf[T](), systemControlStop)
+}
+
+// GetSystemState 查询系统当前的状态，系统不存在时返回false
+func GetSystemState[T SystemObject](getter IUtilityGetter) (SystemState, bool) {
+	sys, ok := getter.getWorld().getSystem(TypeOf[T]())
+	if !ok {
+		return SystemStateInvalid, false
+	}
+	return sys.getState(), true
+}
+
+func RegisterComponent[T ComponentObject, TP ComponentPointer[T]](world IWorld) {
+	world.registerComponent(TP(new(T)))
+}
+
 func AddFreeComponent[T FreeComponentObject, TP FreeComponentPointer[T]](world IWorld, component *T) {
 	world.addFreeComponent(TP(component))
 }
@@ -38,7 +81,60 @@ func GetComponentAll[T ComponentObject](sys ISystem) Iterator[T] {
 	if c == nil {
 		return EmptyIter[T]()
 	}
-	return NewComponentSetIterator[T](c.(*ComponentSet[T]), r.getPermission() == ComponentReadOnly)
+	readOnly := r.getPermission() == ComponentReadOnly
+	iter := NewComponentSetIterator[T](c.(*ComponentSet[T]), readOnly)
+	if !readOnly {
+		iter.(*Iter[T]).trackChanged(sys.World().base().currentTick())
+	}
+	return iter
+}
+
+// GetComponentChanged 遍历系统上次执行后被写访问过（包括新添加）的组件
+func GetComponentChanged[T ComponentObject](sys ISystem) Iterator[T] {
+	iter := GetComponentAll[T](sys)
+	all, ok := iter.(*Iter[T])
+	if !ok {
+		return iter
+	}
+	return &changedIter[T]{Iter: *all, since: sys.lastRunTick()}
+}
+
+// GetRemoved 系统上次执行后被移除组件T的实体，包括随实体销毁而移除的组件，按移除顺序排列，
+// 仅读取移除记录，不需要在系统中声明T
+func GetRemoved[T ComponentObject](sys ISystem) []Entity {
+	if !sys.isExecuting() {
+		return nil
+	}
+	world := sys.World().base()
+	typ := GetType[T]()
+	if !world.componentMeta.Exist(typ) {
+		return nil
+	}
+	set := world.getComponentSet(typ)
+	if set == nil {
+		return nil
+	}
+	var entities []Entity
+	set.rangeRemoved(sys.lastRunTick()+1, func(entity Entity) bool {
+		entities = append(entities, entity)
+		return true
+	})
+	return entities
+}
+
+// GetDestroyed 系统上次执行后被销毁的实体，按销毁顺序排列
+func GetDestroyed(sys ISystem) []Entity {
+	if !sys.isExecuting() {
+		return nil
+	}
+	var entities []Entity
+	sys.World().base().rangeEntityLog(sys.lastRunTick()+1, func(record entityRecord) bool {
+		if record.destroyed {
+			entities = append(entities, record.entity)
+		}
+		return true
+	})
+	return entities
 }
 
 func GetRelated[T ComponentObject](sys ISystem, entity Entity) *T {
@@ -54,6 +150,9 @@ func GetRelated[T ComponentObject](sys ISystem, entity Entity) *T {
 		cache = (*ComponentGetter[T])(c)
 	} else {
 		cache = NewComponentGetter[T](sys)
+		if cache == nil {
+			return nil
+		}
 		cacheMap.Add(typ, unsafe.Pointer(cache))
 	}
 	return cache.Get(entity)
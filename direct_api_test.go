@@ -0,0 +1,175 @@
+package ecs
+
+import "testing"
+
+type __directApi_Test_C_1 struct {
+	Component[__directApi_Test_C_1]
+	Field1 int
+}
+
+type __directApi_Test_Shape_1 struct {
+	c1 *__directApi_Test_C_1 `ecs:"added"`
+}
+
+type __directApi_Test_Shape_2 struct {
+	c1 *__directApi_Test_C_1
+}
+
+type __directApi_Test_S_Writer struct {
+	System[__directApi_Test_S_Writer]
+	target Entity
+}
+
+func (s *__directApi_Test_S_Writer) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__directApi_Test_C_1{})
+	return nil
+}
+
+func (s *__directApi_Test_S_Writer) Update(event Event) {
+	if c := GetComponent[__directApi_Test_C_1](s, s.target); c != nil {
+		c.Field1++
+	}
+	s.target = 0
+}
+
+type __directApi_Test_S_Reader struct {
+	System[__directApi_Test_S_Reader]
+	added   *Shape[__directApi_Test_Shape_1]
+	shape   *Shape[__directApi_Test_Shape_2]
+	changed map[Entity]int
+	shaped  map[Entity]int
+	adds    map[Entity]int
+}
+
+func (s *__directApi_Test_S_Reader) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__directApi_Test_C_1]{})
+	s.added = NewShape[__directApi_Test_Shape_1](si)
+	s.shape = NewShape[__directApi_Test_Shape_2](si, Changed[__directApi_Test_C_1]())
+	return nil
+}
+
+func (s *__directApi_Test_S_Reader) Update(event Event) {
+	iter := GetComponentChanged[__directApi_Test_C_1](s)
+	for c := iter.Begin(); !iter.End(); c = iter.Next() {
+		s.changed[c.Owner()]++
+	}
+	changed := s.shape.Get()
+	for shp := changed.Begin(); !changed.End(); shp = changed.Next() {
+		s.shaped[shp.c1.Owner()]++
+	}
+	shapes := s.added.Get()
+	for shp := shapes.Begin(); !shapes.End(); shp = shapes.Next() {
+		s.adds[shp.c1.Owner()]++
+	}
+}
+
+func TestGetComponentChanged(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__directApi_Test_S_Writer](world)
+	RegisterSystem[__directApi_Test_S_Reader](world)
+	world.Startup()
+
+	w, _ := world.getSystem(TypeOf[__directApi_Test_S_Writer]())
+	writer := w.(*__directApi_Test_S_Writer)
+	r, _ := world.getSystem(TypeOf[__directApi_Test_S_Reader]())
+	reader := r.(*__directApi_Test_S_Reader)
+	reset := func() {
+		reader.changed = map[Entity]int{}
+		reader.shaped = map[Entity]int{}
+		reader.adds = map[Entity]int{}
+	}
+
+	var entities []Entity
+	for i := 0; i < 3; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__directApi_Test_C_1{Field1: i})
+		entities = append(entities, e)
+	}
+	reset()
+	world.Update()
+	if len(reader.changed) != 3 || len(reader.adds) != 3 {
+		t.Errorf("new components should be changed and added, got %v %v", reader.changed, reader.adds)
+	}
+
+	reset()
+	writer.target = entities[1]
+	for i := 0; i < 3; i++ {
+		world.Update()
+	}
+	if len(reader.changed) != 1 || reader.changed[entities[1]] != 1 || len(reader.adds) != 0 {
+		t.Errorf("changed want {%d:1}, got %v, added %v", entities[1], reader.changed, reader.adds)
+	}
+	if len(reader.shaped) != 1 || reader.shaped[entities[1]] != 1 {
+		t.Errorf("shape changed want {%d:1}, got %v", entities[1], reader.shaped)
+	}
+
+	reset()
+	e := world.NewEntity()
+	world.Add(e, &__directApi_Test_C_1{})
+	for i := 0; i < 2; i++ {
+		world.Update()
+	}
+	if len(reader.adds) != 1 || reader.adds[e] != 1 || len(reader.changed) != 1 || reader.changed[e] != 1 {
+		t.Errorf("added want {%d:1}, got %v, changed %v", e, reader.adds, reader.changed)
+	}
+	world.Stop()
+}
+
+type __directApi_Test_S_Cleaner struct {
+	System[__directApi_Test_S_Cleaner]
+	removed   []Entity
+	destroyed []Entity
+}
+
+func (s *__directApi_Test_S_Cleaner) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__directApi_Test_C_1]{})
+	return nil
+}
+
+func (s *__directApi_Test_S_Cleaner) Update(event Event) {
+	s.removed = append(s.removed, GetRemoved[__directApi_Test_C_1](s)...)
+	s.destroyed = append(s.destroyed, GetDestroyed(s)...)
+}
+
+func TestGetRemoved(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__directApi_Test_S_Cleaner](world)
+	world.Startup()
+
+	c, _ := world.getSystem(TypeOf[__directApi_Test_S_Cleaner]())
+	cleaner := c.(*__directApi_Test_S_Cleaner)
+
+	var entities []Entity
+	for i := 0; i < 3; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__directApi_Test_C_1{Field1: i})
+		entities = append(entities, e)
+	}
+	world.Update()
+	if len(cleaner.removed) != 0 || len(cleaner.destroyed) != 0 {
+		t.Fatalf("nothing removed yet, got %v %v", cleaner.removed, cleaner.destroyed)
+	}
+
+	world.Remove(entities[0], &__directApi_Test_C_1{})
+	world.DestroyEntity(entities[1])
+	for i := 0; i < 3; i++ {
+		world.Update()
+	}
+	removed := map[Entity]bool{}
+	for _, e := range cleaner.removed {
+		removed[e] = true
+	}
+	if len(cleaner.removed) != 2 || !removed[entities[0]] || !removed[entities[1]] {
+		t.Errorf("removed want [%d %d], got %v", entities[0], entities[1], cleaner.removed)
+	}
+	if len(cleaner.destroyed) != 1 || cleaner.destroyed[0] != entities[1] {
+		t.Errorf("destroyed want [%d], got %v", entities[1], cleaner.destroyed)
+	}
+	world.Stop()
+}
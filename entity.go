@@ -37,6 +37,9 @@ type EntityIDGenerator struct {
 	removeDelay []RealID
 	delayFree   int32
 	delayCap    int32
+
+	// 生成器创建或加载后分配、回收ID的次数，不参与序列化
+	changes uint64
 }
 
 func NewEntityIDGenerator(initSize int, delayCap int) *EntityIDGenerator {
@@ -68,11 +71,13 @@ func (e *EntityIDGenerator) NewID() Entity {
 		e.free = next
 	}
 	e.len++
+	e.changes++
 	return id.ToEntity()
 }
 
 func (e *EntityIDGenerator) FreeID(entity Entity) {
 	e.len--
+	e.changes++
 
 	realID := entity.ToRealID()
 	e.ids[realID.index].index = -1
@@ -121,3 +126,49 @@ func (e *EntityIDGenerator) delayFlush() {
 	}
 	e.delayFree = 0
 }
+
+// 空闲链表中的下标可以等于len(ids)，表示链表末尾；延迟回收中的下标必须指向已分配的ID
+func (e *EntityIDGenerator) valid() bool {
+	size := int32(len(e.ids))
+	if e.free < 0 || e.free > size || e.pending < 0 || e.pending > size || e.len < 0 || e.len > size {
+		return false
+	}
+	for _, id := range e.ids {
+		if id.index < -1 || id.index > size {
+			return false
+		}
+	}
+	for i := int32(0); i < e.delayFree; i++ {
+		if index := e.removeDelay[i].index; index < 0 || index >= size {
+			return false
+		}
+	}
+	return true
+}
+
+type idGeneratorState struct {
+	ids         []RealID
+	free        int32
+	pending     int32
+	len         int32
+	removeDelay []RealID
+	delayFree   int32
+	delayCap    int32
+	changes     uint64
+}
+
+func (e *EntityIDGenerator) saveState(s *idGeneratorState) {
+	s.ids = append(s.ids[:0], e.ids...)
+	s.removeDelay = append(s.removeDelay[:0], e.removeDelay...)
+	s.free, s.pending, s.len = e.free, e.pending, e.len
+	s.delayFree, s.delayCap = e.delayFree, e.delayCap
+	s.changes = e.changes
+}
+
+func (e *EntityIDGenerator) loadState(s *idGeneratorState) {
+	e.ids = append(e.ids[:0], s.ids...)
+	e.removeDelay = append(e.removeDelay[:0], s.removeDelay...)
+	e.free, e.pending, e.len = s.free, s.pending, s.len
+	e.delayFree, e.delayCap = s.delayFree, s.delayCap
+	e.changes = s.changes
+}
@@ -6,6 +6,7 @@ type EntityInfo struct {
 }
 
 func (e *EntityInfo) Destroy(world IWorld) {
+	world.base().destroyHierarchy(e)
 	for i := 0; i < len(e.compound); i++ {
 		world.deleteComponentByIntType(e.entity, e.compound[i])
 	}
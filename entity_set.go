@@ -11,14 +11,15 @@ func NewEntityCollection() *EntitySet {
 }
 
 func (c *EntitySet) Exist(entity Entity) bool {
-	index := entity.ToRealID().index
-	return c.SparseArray.Exist(index)
+	_, ok := c.GetEntityInfo(entity)
+	return ok
 }
 
+// GetEntityInfo 按索引查找后校验复用计数，索引被新实体复用时旧的Entity查找失败
 func (c *EntitySet) GetEntityInfo(entity Entity) (*EntityInfo, bool) {
 	index := entity.ToRealID().index
 	info := c.Get(index)
-	if info == nil {
+	if info == nil || info.entity != entity {
 		return nil, false
 	}
 	return info, true
@@ -30,6 +31,37 @@ func (c *EntitySet) Add(entityInfo EntityInfo) *EntityInfo {
 }
 
 func (c *EntitySet) Remove(entity Entity) *EntityInfo {
+	if !c.Exist(entity) {
+		return nil
+	}
 	index := entity.ToRealID().index
 	return c.SparseArray.Remove(index)
 }
+
+// 实体的Compound单独深拷贝到连续的内存中
+type entitySetState struct {
+	sparseArrayState[int32, EntityInfo]
+	compounds []uint16
+	ends      []int
+}
+
+func (c *EntitySet) saveState(s *entitySetState) {
+	c.SparseArray.saveState(&s.sparseArrayState)
+	s.compounds = s.compounds[:0]
+	s.ends = s.ends[:0]
+	for i := range s.data {
+		s.compounds = append(s.compounds, s.data[i].compound...)
+		s.ends = append(s.ends, len(s.compounds))
+		s.data[i].compound = nil
+	}
+}
+
+func (c *EntitySet) loadState(s *entitySetState) {
+	c.SparseArray.loadState(&s.sparseArrayState)
+	begin := 0
+	for i := 0; i < int(c.len); i++ {
+		end := s.ends[i]
+		c.data[i].compound = append(NewCompound(end-begin), s.compounds[begin:end]...)
+		begin = end
+	}
+}
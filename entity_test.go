@@ -162,3 +162,78 @@ func BenchmarkEntityIDGenerator_NewFreeRandom(b *testing.B) {
 		}
 	})
 }
+
+type __entity_Test_C_1 struct {
+	Component[__entity_Test_C_1]
+	Field1 int
+}
+
+type __entity_Test_Shape_1 struct {
+	C1 *__entity_Test_C_1
+}
+
+type __entity_Test_S_1 struct {
+	System[__entity_Test_S_1]
+	shape *Shape[__entity_Test_Shape_1]
+}
+
+func (s *__entity_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__entity_Test_C_1{})
+	s.shape = NewShape[__entity_Test_Shape_1](si)
+	return nil
+}
+
+func TestEntity_StaleReference(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__entity_Test_S_1](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__entity_Test_S_1]())
+	sys := s.(*__entity_Test_S_1)
+
+	stale := world.NewEntity()
+	world.Add(stale, &__entity_Test_C_1{Field1: 1})
+	world.Update()
+	world.DestroyEntity(stale)
+	// 回收ID有延迟，多销毁一些实体让索引进入空闲链表
+	for i := 0; i < 16; i++ {
+		world.DestroyEntity(world.NewEntity())
+	}
+	world.Update()
+
+	var reused Entity
+	for i := 0; i < 16 && reused == 0; i++ {
+		if e := world.NewEntity(); e.ToRealID().index == stale.ToRealID().index {
+			reused = e
+		}
+	}
+	if reused == 0 || reused == stale {
+		t.Fatal("index of destroyed entity should be reused with a new reuse counter")
+	}
+	world.Add(reused, &__entity_Test_C_1{Field1: 2})
+	world.Update()
+
+	if world.IsAlive(stale) || !world.IsAlive(reused) || sys.IsAlive(stale) {
+		t.Error("stale entity should not be alive")
+	}
+	if _, ok := sys.GetEntityInfo(stale); ok {
+		t.Error("stale entity info should not be found")
+	}
+	if c := GetRelated[__entity_Test_C_1](sys, stale); c != nil {
+		t.Errorf("stale entity got component of %d", c.Owner())
+	}
+	if _, ok := sys.shape.GetSpecific(stale); ok {
+		t.Error("stale entity should not match shape")
+	}
+
+	// 过期的Entity的操作被忽略，不影响复用索引的实体
+	world.Remove(stale, &__entity_Test_C_1{})
+	world.DestroyEntity(stale)
+	world.Update()
+	if c := GetRelated[__entity_Test_C_1](sys, reused); !world.IsAlive(reused) || c == nil || c.Field1 != 2 {
+		t.Error("reused entity should be unaffected by stale operations")
+	}
+	world.Stop()
+}
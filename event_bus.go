@@ -0,0 +1,106 @@
+package ecs
+
+import "sync"
+
+type eventAccess uint8
+
+const (
+	eventAccessRead eventAccess = 1 << iota
+	eventAccessWrite
+)
+
+type iEventChannel interface {
+	swap()
+	saveState(prev any) any
+	loadState(state any)
+}
+
+// 双缓冲事件通道，本帧写入的事件在下一帧可读，读缓冲只在帧间交换，读写互不影响。
+// 系统内可能并行发送(ParallelForEach)，写缓冲需加锁
+type eventChannel[T any] struct {
+	lock  sync.Mutex
+	read  []T
+	write []T
+}
+
+func (c *eventChannel[T]) send(ev T) {
+	c.lock.Lock()
+	c.write = append(c.write, ev)
+	c.lock.Unlock()
+}
+
+func (c *eventChannel[T]) swap() {
+	c.read, c.write = c.write, c.read[:0]
+}
+
+// 帧间只需保存写缓冲，读缓冲在下一帧开始时被交换
+func (c *eventChannel[T]) saveState(prev any) any {
+	s, _ := prev.([]T)
+	return append(s[:0], c.write...)
+}
+
+func (c *eventChannel[T]) loadState(state any) {
+	s, _ := state.([]T)
+	c.read = c.read[:0]
+	c.write = append(c.write[:0], s...)
+}
+
+func getEventChannel[T any](world *ecsWorld) *eventChannel[T] {
+	typ := TypeOf[T]()
+	ch, ok := world.events[typ]
+	if !ok {
+		ch = &eventChannel[T]{}
+		world.events[typ] = ch
+	}
+	return ch.(*eventChannel[T])
+}
+
+func bindEvent[T any](initializer SystemInitConstraint, access eventAccess) {
+	if initializer.isValid() {
+		panic("out of initialization stage")
+	}
+	sys := initializer.getSystem()
+	getEventChannel[T](sys.World().base())
+	sys.bindEvent(TypeOf[T](), access)
+}
+
+// BindEventWriter 在Init中声明系统会发送事件T，发送同一事件的系统不会并行执行
+func BindEventWriter[T any](initializer SystemInitConstraint) {
+	bindEvent[T](initializer, eventAccessWrite)
+}
+
+// BindEventReader 在Init中声明系统会读取事件T
+func BindEventReader[T any](initializer SystemInitConstraint) {
+	bindEvent[T](initializer, eventAccessRead)
+}
+
+// SendEvent 发送事件，下一帧对读取者可见，可以在ParallelForEach中并发调用
+func SendEvent[T any](sys ISystem, ev T) {
+	if !sys.isExecuting() {
+		return
+	}
+	typ := TypeOf[T]()
+	if sys.getEvents()[typ]&eventAccessWrite == 0 {
+		Log.Errorf("system %s does not bind event writer %s", sys.Type().Name(), typ.Name())
+		return
+	}
+	getEventChannel[T](sys.World().base()).send(ev)
+}
+
+// ReadEvents 读取上一帧发送的事件，返回的切片只读，在下一帧失效
+func ReadEvents[T any](sys ISystem) []T {
+	if !sys.isExecuting() {
+		return nil
+	}
+	typ := TypeOf[T]()
+	if sys.getEvents()[typ]&eventAccessRead == 0 {
+		return nil
+	}
+	return getEventChannel[T](sys.World().base()).read
+}
+
+func (w *ecsWorld) swapEvents() {
+	for _, ch := range w.events {
+		ch.swap()
+	}
+}
@@ -0,0 +1,153 @@
+package ecs
+
+import (
+	"runtime"
+	"testing"
+)
+
+type __eventBus_Test_E_1 struct {
+	Value int
+}
+
+type __eventBus_Test_S_Writer1 struct {
+	System[__eventBus_Test_S_Writer1]
+	send []int
+}
+
+func (s *__eventBus_Test_S_Writer1) Init(si SystemInitConstraint) error {
+	BindEventWriter[__eventBus_Test_E_1](si)
+	return nil
+}
+
+func (s *__eventBus_Test_S_Writer1) Update(event Event) {
+	for _, v := range s.send {
+		SendEvent(s, __eventBus_Test_E_1{Value: v})
+	}
+	s.send = nil
+}
+
+type __eventBus_Test_S_Writer2 struct {
+	System[__eventBus_Test_S_Writer2]
+	send []int
+}
+
+func (s *__eventBus_Test_S_Writer2) Init(si SystemInitConstraint) error {
+	BindEventWriter[__eventBus_Test_E_1](si)
+	return nil
+}
+
+func (s *__eventBus_Test_S_Writer2) Update(event Event) {
+	for _, v := range s.send {
+		SendEvent(s, __eventBus_Test_E_1{Value: v})
+	}
+	s.send = nil
+}
+
+type __eventBus_Test_S_Reader struct {
+	System[__eventBus_Test_S_Reader]
+	received []int
+}
+
+func (s *__eventBus_Test_S_Reader) Init(si SystemInitConstraint) error {
+	BindEventReader[__eventBus_Test_E_1](si)
+	return nil
+}
+
+func (s *__eventBus_Test_S_Reader) Update(event Event) {
+	s.received = s.received[:0]
+	for _, e := range ReadEvents[__eventBus_Test_E_1](s) {
+		s.received = append(s.received, e.Value)
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__eventBus_Test_S_Writer1](world)
+	RegisterSystem[__eventBus_Test_S_Writer2](world)
+	RegisterSystem[__eventBus_Test_S_Reader](world)
+	world.Startup()
+
+	s, _ := world.getSystem(TypeOf[__eventBus_Test_S_Writer1]())
+	w1 := s.(*__eventBus_Test_S_Writer1)
+	s, _ = world.getSystem(TypeOf[__eventBus_Test_S_Writer2]())
+	w2 := s.(*__eventBus_Test_S_Writer2)
+	s, _ = world.getSystem(TypeOf[__eventBus_Test_S_Reader]())
+	reader := s.(*__eventBus_Test_S_Reader)
+
+	sg := world.systemFlow.stages[StageUpdate][1]
+	sg.resort()
+	if sg.batchCount() != 2 {
+		t.Errorf("writers of the same event should not run in the same batch, batch count %d", sg.batchCount())
+	}
+
+	w1.send = []int{1, 2}
+	w2.send = []int{3}
+	world.Update()
+	if len(reader.received) != 0 {
+		t.Errorf("events should be readable next frame, got %v", reader.received)
+	}
+
+	world.Update()
+	sum := 0
+	for _, v := range reader.received {
+		sum += v
+	}
+	if len(reader.received) != 3 || sum != 6 {
+		t.Errorf("events want [1 2 3], got %v", reader.received)
+	}
+
+	world.Update()
+	if len(reader.received) != 0 {
+		t.Errorf("events should be dropped after one frame, got %v", reader.received)
+	}
+	world.Stop()
+}
+
+type __eventBus_Test_C_1 struct {
+	Component[__eventBus_Test_C_1]
+	Value int
+}
+
+type __eventBus_Test_S_ParallelWriter struct {
+	System[__eventBus_Test_S_ParallelWriter]
+}
+
+func (s *__eventBus_Test_S_ParallelWriter) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__eventBus_Test_C_1]{})
+	BindEventWriter[__eventBus_Test_E_1](si)
+	return nil
+}
+
+func (s *__eventBus_Test_S_ParallelWriter) Update(event Event) {
+	ParallelForEach[__eventBus_Test_C_1](s, 16, func(c *__eventBus_Test_C_1) {
+		SendEvent(s, __eventBus_Test_E_1{Value: c.Value})
+		// 让出执行，使协程池中的辅助任务参与发送
+		runtime.Gosched()
+	})
+}
+
+// 系统内并行发送事件，需配合-race运行
+func TestEventBus_ParallelSend(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__eventBus_Test_S_ParallelWriter](world)
+	RegisterSystem[__eventBus_Test_S_Reader](world)
+	world.Startup()
+
+	for i := 0; i < 1000; i++ {
+		world.Add(world.NewEntity(), &__eventBus_Test_C_1{Value: 1})
+	}
+	world.Update()
+	world.Update()
+
+	s, _ := world.getSystem(TypeOf[__eventBus_Test_S_Reader]())
+	if got := len(s.(*__eventBus_Test_S_Reader).received); got != 1000 {
+		t.Errorf("events want 1000, got %d", got)
+	}
+	world.Stop()
+}
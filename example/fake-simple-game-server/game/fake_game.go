@@ -45,23 +45,25 @@ func (f *FakeGame) InitEcs() {
 	ecs.RegisterSystem[MoveSystem](f.world)
 	ecs.RegisterSystem[SyncSystem](f.world)
 	ecs.RegisterSystem[EmptySystem](f.world)
+
+	//register prefabs
+	err := f.world.RegisterPrefab(ecs.NewPrefab("player",
+		&Position{X: 100, Y: 100, Z: 100},
+		&Movement{V: 2000, Dir: [3]int{1, 0, 0}},
+	))
+	if err != nil {
+		ecs.Log.Error(err)
+	}
 }
 
 func (f *FakeGame) EnterGame(sess *Session) {
 	f.world.Wait(func(gaw ecs.SyncWrapper) error {
-		e := gaw.NewEntity()
-		gaw.Add(e, &PlayerComponent{
+		e, err := gaw.Spawn("player", &PlayerComponent{
 			SessionID: sess.SessionID,
 		})
-		gaw.Add(e, &Position{
-			X: 100,
-			Y: 100,
-			Z: 100,
-		})
-		gaw.Add(e, &Movement{
-			V:   2000,
-			Dir: [3]int{1, 0, 0},
-		})
+		if err != nil {
+			return err
+		}
 		sess.Entity = e
 		return nil
 	})
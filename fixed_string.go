@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"encoding/json"
 	"fmt"
 	"unsafe"
 )
@@ -39,3 +40,20 @@ func (f *FixedString[T]) Set(s string) {
 		copy((*(*[__FixedMax]byte)(unsafe.Pointer(&(f.data))))[:unsafe.Sizeof(f.data)], s)
 	}
 }
+
+// MarshalJSON FixedString以普通字符串的形式输出
+func (f FixedString[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+func (f *FixedString[T]) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if len(s) > int(unsafe.Sizeof(f.data)) {
+		return fmt.Errorf("fixed string max size: %d, received size: %d", unsafe.Sizeof(f.data), len(s))
+	}
+	f.Set(s)
+	return nil
+}
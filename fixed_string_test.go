@@ -2,6 +2,7 @@ package ecs
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
@@ -55,3 +56,19 @@ func TestFixedString_String(t *testing.T) {
 		})
 	}
 }
+
+func TestFixedString_JSON(t *testing.T) {
+	f := FixedString[Fixed16]{}
+	f.Set("hello")
+	b, err := json.Marshal(&f)
+	if err != nil || string(b) != `"hello"` {
+		t.Fatalf("MarshalJSON() = %s, %v", b, err)
+	}
+	g := FixedString[Fixed16]{}
+	if err = json.Unmarshal(b, &g); err != nil || g.String() != "hello" {
+		t.Errorf("UnmarshalJSON() = %s, %v", g.String(), err)
+	}
+	if err = json.Unmarshal([]byte(`"more than sixteen bytes"`), &g); err == nil {
+		t.Error("UnmarshalJSON() should fail when string is too long")
+	}
+}
@@ -2,46 +2,139 @@ package ecs
 
 import (
 	runtime2 "runtime"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// 双端任务队列，所属worker从尾部取任务，其他worker从头部窃取。
+// 任务执行时传入执行它的worker，任务中可以通过该worker向其本地队列投递任务
+type jobDeque struct {
+	lock sync.Mutex
+	jobs []func(w *Worker)
+	head int
+}
+
+func (d *jobDeque) push(jobs ...func(w *Worker)) {
+	d.lock.Lock()
+	d.jobs = append(d.jobs, jobs...)
+	d.lock.Unlock()
+}
+
+func (d *jobDeque) popBack() func(w *Worker) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.jobs) == d.head {
+		return nil
+	}
+	last := len(d.jobs) - 1
+	job := d.jobs[last]
+	d.jobs[last] = nil
+	d.jobs = d.jobs[:last]
+	d.reset()
+	return job
+}
+
+func (d *jobDeque) popFront() func(w *Worker) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.jobs) == d.head {
+		return nil
+	}
+	job := d.jobs[d.head]
+	d.jobs[d.head] = nil
+	d.head++
+	d.reset()
+	return job
+}
+
+func (d *jobDeque) reset() {
+	if d.head == len(d.jobs) {
+		d.jobs = d.jobs[:0]
+		d.head = 0
+	}
+}
+
+// WorkerStats worker的运行统计
+type WorkerStats struct {
+	Executed uint64        //执行的任务数
+	Stolen   uint64        //从其他worker窃取的任务数
+	Busy     time.Duration //执行任务的总时间
+}
+
 // Worker goroutine struct.
 type Worker struct {
 	p        *Pool
-	jobQueue chan func()
-	stop     chan struct{}
+	index    int
+	local    jobDeque
+	pinned   jobDeque
+	wake     chan struct{}
+	idle     int32
+	executed uint64
+	stolen   uint64
+	busy     int64
 }
 
 // Start goroutine pool.
 func (w *Worker) Start() {
-	c := func() (c bool) {
-		//defer func() {
-		//	if r := recover(); r != nil {
-		//		Log.Error(r)
-		//	}
-		//	c = true
-		//}()
-		var job func()
-		for {
-			select {
-			case job = <-w.jobQueue:
-			case job = <-w.p.jobQueue:
-			case <-w.stop:
-				return
+	for {
+		job, stolen := w.next()
+		if job == nil {
+			// 先标记空闲再检查一次，避免投递与休眠之间丢失唤醒
+			atomic.StoreInt32(&w.idle, 1)
+			job, stolen = w.next()
+			if job == nil {
+				select {
+				case <-w.wake:
+					atomic.StoreInt32(&w.idle, 0)
+					continue
+				case <-w.p.stop:
+					return
+				}
 			}
-			job()
+			atomic.StoreInt32(&w.idle, 0)
+		}
+		atomic.AddInt64(&w.p.pending, -1)
+		start := time.Now()
+		job(w)
+		atomic.AddInt64(&w.busy, int64(time.Since(start)))
+		atomic.AddUint64(&w.executed, 1)
+		if stolen {
+			atomic.AddUint64(&w.stolen, 1)
+		}
+	}
+}
+
+// 依次从固定任务、本地队列、公共队列中取任务，都没有时从其他worker窃取
+func (w *Worker) next() (func(w *Worker), bool) {
+	if job := w.pinned.popFront(); job != nil {
+		return job, false
+	}
+	if job := w.local.popBack(); job != nil {
+		return job, false
+	}
+	if job := w.p.global.popFront(); job != nil {
+		return job, false
+	}
+	size := len(w.p.workers)
+	for i := 1; i < size; i++ {
+		if job := w.p.workers[(w.index+i)%size].local.popFront(); job != nil {
+			return job, true
 		}
-	}()
-	if c {
-		go w.Start()
 	}
+	return nil, false
 }
 
 // Pool is goroutine pool config.
 type Pool struct {
 	size         uint32
 	jobQueueSize uint32
-	jobQueue     chan func()
+	global       jobDeque
 	workers      []*Worker
+	pending      int64
+	stop         chan struct{}
+	stopOnce     sync.Once
+	next         uint32
 }
 
 // NewPool news goroutine pool
@@ -52,42 +145,114 @@ func NewPool(size uint32, jobQueueSize uint32) *Pool {
 	if jobQueueSize == 0 {
 		jobQueueSize = uint32(runtime2.NumCPU())
 	}
-	jobQueue := make(chan func(), jobQueueSize*size)
-	workerQueue := make([]*Worker, size)
-
 	pool := &Pool{
-		size:         uint32(size),
-		jobQueueSize: uint32(jobQueueSize),
-		jobQueue:     jobQueue,
-		workers:      workerQueue,
-	}
-	for i := 0; i < cap(pool.workers); i++ {
-		worker := &Worker{
-			p:        pool,
-			jobQueue: make(chan func(), pool.jobQueueSize),
-			stop:     make(chan struct{}),
+		size:         size,
+		jobQueueSize: jobQueueSize,
+		workers:      make([]*Worker, size),
+		stop:         make(chan struct{}),
+	}
+	for i := 0; i < len(pool.workers); i++ {
+		pool.workers[i] = &Worker{
+			p:     pool,
+			index: i,
+			wake:  make(chan struct{}, 1),
 		}
-		pool.workers[i] = worker
 	}
 	return pool
 }
 
+// 唤醒最多n个空闲的worker
+func (p *Pool) notify(n int) {
+	for _, w := range p.workers {
+		if n <= 0 {
+			return
+		}
+		if atomic.LoadInt32(&w.idle) == 0 {
+			continue
+		}
+		select {
+		case w.wake <- struct{}{}:
+			n--
+		default:
+		}
+	}
+}
+
 // Add hashKey is an optional parameter, job will be executed in a random worker
 // when hashKey is regardless, in fixed worker calculated by hash when hashKey is
-// specified
+// specified. Add不会阻塞，也不受jobQueueSize限制，需要感知积压时使用TryAdd
 func (p *Pool) Add(job func(), hashKey ...uint32) {
+	atomic.AddInt64(&p.pending, 1)
 	if len(hashKey) > 0 {
-		p.workers[hashKey[0]%p.size].jobQueue <- job
+		w := p.workers[hashKey[0]%p.size]
+		w.pinned.push(wrapJob(job))
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
 		return
 	}
-	p.jobQueue <- job
+	p.global.push(wrapJob(job))
+	p.notify(1)
+}
+
+// TryAdd 积压的任务数达到队列容量(size*jobQueueSize)时不投递，返回false
+func (p *Pool) TryAdd(job func()) bool {
+	return p.tryAdd(nil, wrapJob(job))
+}
+
+// from为投递者所在的worker，任务放入其本地队列，空闲的worker可以窃取；不在协程池中时为nil，放入公共队列
+func (p *Pool) tryAdd(from *Worker, job func(w *Worker)) bool {
+	if atomic.LoadInt64(&p.pending) >= int64(p.size*p.jobQueueSize) {
+		return false
+	}
+	atomic.AddInt64(&p.pending, 1)
+	if from != nil {
+		from.local.push(job)
+	} else {
+		p.global.push(job)
+	}
+	p.notify(1)
+	return true
+}
+
+func wrapJob(job func()) func(w *Worker) {
+	return func(w *Worker) {
+		job()
+	}
+}
+
+// AddBatch 批量投递任务，任务轮流分配到各worker的本地队列，空闲的worker会窃取其他worker的任务
+func (p *Pool) AddBatch(jobs []func()) {
+	wrapped := make([]func(w *Worker), len(jobs))
+	for i, job := range jobs {
+		wrapped[i] = wrapJob(job)
+	}
+	p.addBatch(wrapped)
+}
+
+// 批量投递需要知道执行者的任务，如系统的执行任务
+func (p *Pool) addBatch(jobs []func(w *Worker)) {
+	if len(jobs) == 0 {
+		return
+	}
+	atomic.AddInt64(&p.pending, int64(len(jobs)))
+	start := int(atomic.AddUint32(&p.next, 1))
+	size := len(p.workers)
+	for i := 0; i < size && i < len(jobs); i++ {
+		w := p.workers[(start+i)%size]
+		var batch []func(w *Worker)
+		for j := i; j < len(jobs); j += size {
+			batch = append(batch, jobs[j])
+		}
+		w.local.push(batch...)
+	}
+	p.notify(len(jobs))
 }
 
 // Start all workers
 func (p *Pool) Start() {
-	var worker *Worker
-	for i := 0; i < cap(p.workers); i++ {
-		worker = p.workers[i]
+	for _, worker := range p.workers {
 		go worker.Start()
 	}
 }
@@ -97,9 +262,27 @@ func (p *Pool) Size() uint32 {
 	return p.size
 }
 
+// Pending 已投递但尚未开始执行的任务数
+func (p *Pool) Pending() int {
+	return int(atomic.LoadInt64(&p.pending))
+}
+
+// Stats 各worker的运行统计
+func (p *Pool) Stats() []WorkerStats {
+	stats := make([]WorkerStats, len(p.workers))
+	for i, w := range p.workers {
+		stats[i] = WorkerStats{
+			Executed: atomic.LoadUint64(&w.executed),
+			Stolen:   atomic.LoadUint64(&w.stolen),
+			Busy:     time.Duration(atomic.LoadInt64(&w.busy)),
+		}
+	}
+	return stats
+}
+
 // Release rtStop all workers
 func (p *Pool) Release() {
-	for _, worker := range p.workers {
-		worker.stop <- struct{}{}
-	}
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
 }
@@ -0,0 +1,94 @@
+package ecs
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool_AddBatchSteal(t *testing.T) {
+	pool := NewPool(4, 2)
+	pool.Start()
+	defer pool.Release()
+
+	// 阻塞一个worker，分配给它的批量任务只能被其他worker窃取
+	block := make(chan struct{})
+	pool.Add(func() { <-block }, 0)
+	time.Sleep(time.Millisecond * 10)
+
+	var wg sync.WaitGroup
+	jobs := make([]func(), 16)
+	for i := range jobs {
+		jobs[i] = func() { wg.Done() }
+	}
+	wg.Add(len(jobs))
+	pool.AddBatch(jobs)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch jobs of the blocked worker were not stolen")
+	}
+	close(block)
+
+	var executed, stolen uint64
+	for _, s := range pool.Stats() {
+		executed += s.Executed
+		stolen += s.Stolen
+	}
+	if executed < 16 || stolen == 0 {
+		t.Errorf("stats executed %d stolen %d", executed, stolen)
+	}
+}
+
+func TestPool_TryAdd(t *testing.T) {
+	pool := NewPool(2, 2)
+	for i := 0; i < 4; i++ {
+		if !pool.TryAdd(func() {}) {
+			t.Fatalf("job %d should be accepted", i)
+		}
+	}
+	if pool.TryAdd(func() {}) {
+		t.Error("full pool should report back-pressure")
+	}
+	pool.Start()
+	defer pool.Release()
+	for i := 0; i < 100 && pool.Pending() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if !pool.TryAdd(func() {}) {
+		t.Error("drained pool should accept jobs")
+	}
+}
+
+// 协程池中的任务通过传入的worker投递到其本地队列，池外投递到公共队列
+func TestPool_LocalAdd(t *testing.T) {
+	pool := NewPool(2, 4)
+	w := pool.workers[1]
+	if !pool.tryAdd(w, func(*Worker) {}) || len(w.local.jobs) != 1 || len(pool.global.jobs) != 0 {
+		t.Errorf("job from worker should be pushed to its local queue")
+	}
+	if !pool.TryAdd(func() {}) || len(pool.global.jobs) != 1 {
+		t.Errorf("job from outside should be pushed to the global queue")
+	}
+
+	done := make(chan *Worker, 1)
+	pool.addBatch([]func(w *Worker){func(w *Worker) {
+		done <- w
+	}})
+	pool.Start()
+	defer pool.Release()
+	select {
+	case got := <-done:
+		if got == nil {
+			t.Error("job should receive the worker executing it")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("job not executed")
+	}
+}
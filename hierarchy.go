@@ -0,0 +1,265 @@
+package ecs
+
+import "unsafe"
+
+// Parent 实体的父实体，由框架维护，通过SetParent、RemoveParent修改，不能直接添加或移除。
+// Prev、Next为同一父实体下的兄弟实体
+type Parent struct {
+	Component[Parent]
+	Entity Entity
+	Prev   Entity
+	Next   Entity
+}
+
+// Children 实体的子实体链表，由框架维护，First、Last为第一个和最后一个子实体
+type Children struct {
+	Component[Children]
+	First Entity
+	Last  Entity
+	Count int
+}
+
+type hierarchyOp struct {
+	entity  Entity
+	parent  Entity
+	destroy bool
+}
+
+// SetParent 将child挂到parent下，原有父实体时先解除，在下一个同步点生效，parent为0时等同于RemoveParent
+func SetParent(world IWorld, child Entity, parent Entity) {
+	world.base().pushHierarchy(hierarchyOp{entity: child, parent: parent})
+}
+
+// RemoveParent 解除child与父实体的关系，在下一个同步点生效
+func RemoveParent(world IWorld, child Entity) {
+	world.base().pushHierarchy(hierarchyOp{entity: child})
+}
+
+// GetParent 实体的父实体
+func GetParent(sys ISystem, entity Entity) (Entity, bool) {
+	w := sys.World().base()
+	p := w.hierarchyComponent(entity, w.parentMeta)
+	if p == nil {
+		return 0, false
+	}
+	return (*Parent)(p).Entity, true
+}
+
+// GetChildren 实体的直接子实体，按挂载顺序排列
+func GetChildren(sys ISystem, entity Entity) []Entity {
+	w := sys.World().base()
+	var children []Entity
+	w.rangeChildren(entity, func(child Entity) bool {
+		children = append(children, child)
+		return true
+	})
+	return children
+}
+
+// DepthFirst 从root开始深度优先遍历子树（不含root），depth为相对root的深度，fn返回false时停止遍历
+func DepthFirst(sys ISystem, root Entity, fn func(entity Entity, depth int) bool) {
+	w := sys.World().base()
+	var walk func(entity Entity, depth int) bool
+	walk = func(entity Entity, depth int) bool {
+		ok := true
+		w.rangeChildren(entity, func(child Entity) bool {
+			ok = fn(child, depth) && walk(child, depth+1)
+			return ok
+		})
+		return ok
+	}
+	walk(root, 1)
+}
+
+// BreadthFirst 从root开始广度优先遍历子树（不含root），depth为相对root的深度，fn返回false时停止遍历
+func BreadthFirst(sys ISystem, root Entity, fn func(entity Entity, depth int) bool) {
+	w := sys.World().base()
+	level := []Entity{root}
+	for depth := 1; len(level) > 0; depth++ {
+		var next []Entity
+		for _, entity := range level {
+			ok := true
+			w.rangeChildren(entity, func(child Entity) bool {
+				if ok = fn(child, depth); ok {
+					next = append(next, child)
+				}
+				return ok
+			})
+			if !ok {
+				return
+			}
+		}
+		level = next
+	}
+}
+
+func (w *ecsWorld) initHierarchy() {
+	w.parentMeta = w.getOrCreateComponentMetaInfo(&Parent{})
+	w.childrenMeta = w.getOrCreateComponentMetaInfo(&Children{})
+}
+
+func (w *ecsWorld) pushHierarchy(op hierarchyOp) {
+	w.hierarchyLock.Lock()
+	w.hierarchyOps = append(w.hierarchyOps, op)
+	w.hierarchyLock.Unlock()
+}
+
+// 实体销毁时，存在父子关系的实体需要在同步点解除关系并销毁子实体
+func (w *ecsWorld) destroyHierarchy(info *EntityInfo) {
+	if info.compound.Exist(w.parentMeta.it) || info.compound.Exist(w.childrenMeta.it) {
+		w.pushHierarchy(hierarchyOp{entity: info.entity, destroy: true})
+	}
+}
+
+func (w *ecsWorld) hierarchyComponent(entity Entity, meta *ComponentMetaInfo) unsafe.Pointer {
+	set := w.getComponentSetByIntType(meta.it)
+	if set == nil {
+		return nil
+	}
+	return set.getPointerByEntity(entity)
+}
+
+func (w *ecsWorld) rangeChildren(entity Entity, fn func(child Entity) bool) {
+	p := w.hierarchyComponent(entity, w.childrenMeta)
+	if p == nil {
+		return
+	}
+	for child := (*Children)(p).First; child != 0; {
+		cp := w.hierarchyComponent(child, w.parentMeta)
+		if cp == nil {
+			return
+		}
+		next := (*Parent)(cp).Next
+		if !fn(child) {
+			return
+		}
+		child = next
+	}
+}
+
+// 同步点，在组件操作生效前应用父子关系的变更。销毁实体的组件此时还未移除，子实体的销毁会继续加入队列，在本次一并处理
+func (w *ecsWorld) applyHierarchy() {
+	for {
+		w.hierarchyLock.Lock()
+		ops := w.hierarchyOps
+		w.hierarchyOps = nil
+		w.hierarchyLock.Unlock()
+		if len(ops) == 0 {
+			return
+		}
+		for _, op := range ops {
+			if op.destroy {
+				w.unlinkParent(op.entity)
+				var children []Entity
+				w.rangeChildren(op.entity, func(child Entity) bool {
+					children = append(children, child)
+					return true
+				})
+				for _, child := range children {
+					if info, ok := w.getEntityInfo(child); ok {
+						info.Destroy(w)
+					}
+				}
+				continue
+			}
+			if !w.IsAlive(op.entity) {
+				continue
+			}
+			if op.parent != 0 && !w.canAttach(op.entity, op.parent) {
+				continue
+			}
+			w.unlinkParent(op.entity)
+			if op.parent != 0 {
+				w.linkParent(op.entity, op.parent)
+			}
+		}
+	}
+}
+
+// 父实体需存活，且不能是child本身或child的后代
+func (w *ecsWorld) canAttach(child Entity, parent Entity) bool {
+	if !w.IsAlive(parent) {
+		Log.Errorf("set parent of %d failed, parent %d is not alive", child, parent)
+		return false
+	}
+	for e := parent; e != 0; {
+		if e == child {
+			Log.Errorf("set parent of %d failed, %d is its descendant", child, parent)
+			return false
+		}
+		p := w.hierarchyComponent(e, w.parentMeta)
+		if p == nil {
+			break
+		}
+		e = (*Parent)(p).Entity
+	}
+	return true
+}
+
+// 从父实体的子实体链表中移除并删除Parent组件，子实体为空时删除父实体的Children组件
+func (w *ecsWorld) unlinkParent(entity Entity) {
+	p := w.hierarchyComponent(entity, w.parentMeta)
+	if p == nil {
+		return
+	}
+	tick := w.currentTick()
+	link := (*Parent)(p)
+	if prev := w.hierarchyComponent(link.Prev, w.parentMeta); prev != nil {
+		(*Parent)(prev).Next = link.Next
+		markChanged(prev, tick)
+	}
+	if next := w.hierarchyComponent(link.Next, w.parentMeta); next != nil {
+		(*Parent)(next).Prev = link.Prev
+		markChanged(next, tick)
+	}
+	if cp := w.hierarchyComponent(link.Entity, w.childrenMeta); cp != nil {
+		children := (*Children)(cp)
+		if children.First == entity {
+			children.First = link.Next
+		}
+		if children.Last == entity {
+			children.Last = link.Prev
+		}
+		children.Count--
+		markChanged(cp, tick)
+		if children.Count <= 0 {
+			w.detachComponent(link.Entity, w.childrenMeta)
+		}
+	}
+	w.detachComponent(entity, w.parentMeta)
+}
+
+// 挂到父实体的子实体链表末尾
+func (w *ecsWorld) linkParent(entity Entity, parent Entity) {
+	tick := w.currentTick()
+	cp := w.hierarchyComponent(parent, w.childrenMeta)
+	if cp == nil {
+		w.restoreComponent(parent, &Children{})
+		cp = w.hierarchyComponent(parent, w.childrenMeta)
+	}
+	children := (*Children)(cp)
+	link := &Parent{Entity: parent, Prev: children.Last}
+	if last := w.hierarchyComponent(children.Last, w.parentMeta); last != nil {
+		(*Parent)(last).Next = entity
+		markChanged(last, tick)
+	}
+	if children.First == 0 {
+		children.First = entity
+	}
+	children.Last = entity
+	children.Count++
+	markChanged(cp, tick)
+	w.restoreComponent(entity, link)
+}
+
+// 跳过操作队列直接移除组件
+func (w *ecsWorld) detachComponent(entity Entity, meta *ComponentMetaInfo) {
+	set := w.getComponentSetByIntType(meta.it)
+	if set == nil {
+		return
+	}
+	set.removeAndRecord(entity, w.currentTick())
+	if info, ok := w.getEntityInfo(entity); ok {
+		info.removeFromCompound(meta.it)
+	}
+}
@@ -0,0 +1,85 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type __hierarchy_Test_S_1 struct {
+	System[__hierarchy_Test_S_1]
+}
+
+func TestHierarchy(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__hierarchy_Test_S_1](world)
+	world.Startup()
+	sys, _ := world.getSystem(TypeOf[__hierarchy_Test_S_1]())
+
+	root, a, b := world.NewEntity(), world.NewEntity(), world.NewEntity()
+	a1, a2, b1 := world.NewEntity(), world.NewEntity(), world.NewEntity()
+	world.SetParent(a, root)
+	world.SetParent(b, root)
+	world.SetParent(a1, a)
+	world.SetParent(a2, a)
+	world.SetParent(b1, b)
+	world.Update()
+
+	traverse := func(walk func(sys ISystem, root Entity, fn func(entity Entity, depth int) bool)) ([]Entity, []int) {
+		var entities []Entity
+		var depths []int
+		walk(sys, root, func(entity Entity, depth int) bool {
+			entities = append(entities, entity)
+			depths = append(depths, depth)
+			return true
+		})
+		return entities, depths
+	}
+	check := func(name string, got, want any) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s want %v, got %v", name, want, got)
+		}
+	}
+
+	check("children", GetChildren(sys, root), []Entity{a, b})
+	dfs, depths := traverse(DepthFirst)
+	check("depth first", dfs, []Entity{a, a1, a2, b, b1})
+	check("depth first depths", depths, []int{1, 2, 2, 1, 2})
+	bfs, depths := traverse(BreadthFirst)
+	check("breadth first", bfs, []Entity{a, b, a1, a2, b1})
+	check("breadth first depths", depths, []int{1, 1, 2, 2, 2})
+
+	// 重新挂载，环会被拒绝
+	world.SetParent(a2, b)
+	world.SetParent(root, a1)
+	world.Update()
+	check("reparent a", GetChildren(sys, a), []Entity{a1})
+	check("reparent b", GetChildren(sys, b), []Entity{b1, a2})
+	if parent, ok := GetParent(sys, a2); !ok || parent != b {
+		t.Errorf("parent of a2 want %d, got %d", b, parent)
+	}
+	if _, ok := GetParent(sys, root); ok {
+		t.Error("cycle should be rejected")
+	}
+
+	// 销毁父实体时递归销毁子实体
+	info, _ := world.getEntityInfo(a)
+	info.Destroy(world)
+	world.Update()
+	if world.IsAlive(a) || world.IsAlive(a1) {
+		t.Error("children should be destroyed with parent")
+	}
+	check("after destroy", GetChildren(sys, root), []Entity{b})
+
+	world.SetParent(b, 0)
+	world.Update()
+	if _, ok := GetParent(sys, b); ok {
+		t.Error("b should be detached")
+	}
+	check("after detach", GetChildren(sys, root), []Entity(nil))
+	check("subtree kept", GetChildren(sys, b), []Entity{b1, a2})
+	world.Stop()
+}
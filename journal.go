@@ -0,0 +1,116 @@
+package ecs
+
+import "unsafe"
+
+// 变更记录使用变更序号(tick)标记：每帧开始、每批系统执行前、系统执行后的同步点各递增一次，
+// 帧间（如AsyncWorld.Sync）的修改使用当前帧开始时的tick。组件头中记录组件添加和最后一次写访问时的tick，
+// tick为uint32，比较时按序列号方式处理回绕。
+
+type removedRecord struct {
+	entity Entity
+	tick   uint32
+}
+
+type entityRecord struct {
+	entity    Entity
+	tick      uint32
+	destroyed bool
+}
+
+type frameTick struct {
+	frame uint64
+	tick  uint32
+}
+
+func tickBefore(a, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+// 写访问时标记组件的修改tick，p为组件指针
+func markChanged(p unsafe.Pointer, tick uint32) {
+	(*EmptyComponent)(p).changed = tick
+}
+
+func (w *ecsWorld) currentTick() uint32 {
+	return w.tick
+}
+
+func (w *ecsWorld) advanceTick() uint32 {
+	w.tick++
+	return w.tick
+}
+
+// 进入新的一帧，记录该帧开始时的tick
+func (w *ecsWorld) beginFrameTick() {
+	w.frameTicks = append(w.frameTicks, frameTick{frame: w.frame, tick: w.advanceTick()})
+}
+
+// 帧开始时的tick，frame早于保留范围时返回false
+func (w *ecsWorld) tickOfFrame(frame uint64) (uint32, bool) {
+	if frame < w.journalStart {
+		return 0, false
+	}
+	if frame > w.frame {
+		return w.tick + 1, true
+	}
+	for i := len(w.frameTicks) - 1; i >= 0; i-- {
+		if w.frameTicks[i].frame <= frame {
+			return w.frameTicks[i].tick, true
+		}
+	}
+	return 0, false
+}
+
+func (w *ecsWorld) recordEntity(entity Entity, destroyed bool) {
+	w.entityLog = append(w.entityLog, entityRecord{entity: entity, tick: w.currentTick(), destroyed: destroyed})
+}
+
+func (w *ecsWorld) rangeEntityLog(since uint32, fn func(record entityRecord) bool) {
+	start := len(w.entityLog)
+	for start > 0 && !tickBefore(w.entityLog[start-1].tick, since) {
+		start--
+	}
+	for i := start; i < len(w.entityLog); i++ {
+		if !fn(w.entityLog[i]) {
+			break
+		}
+	}
+}
+
+// 丢弃超出HistoryFrames的变更记录
+func (w *ecsWorld) trimJournal() {
+	history := uint64(w.config.HistoryFrames)
+	if w.frame < history || w.frame-history <= w.journalStart {
+		return
+	}
+	w.journalStart = w.frame - history
+
+	i := 0
+	for ; i+1 < len(w.frameTicks) && w.frameTicks[i+1].frame <= w.journalStart; i++ {
+	}
+	if i > 0 {
+		n := copy(w.frameTicks, w.frameTicks[i:])
+		w.frameTicks = w.frameTicks[:n]
+	}
+	since := w.frameTicks[0].tick
+
+	i = 0
+	for ; i < len(w.entityLog) && tickBefore(w.entityLog[i].tick, since); i++ {
+	}
+	if i > 0 {
+		n := copy(w.entityLog, w.entityLog[i:])
+		w.entityLog = w.entityLog[:n]
+	}
+
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		(*set).trimRemoved(since)
+		return true
+	})
+}
+
+func (w *ecsWorld) resetJournal() {
+	w.entityLog = nil
+	w.journalStart = w.frame
+	w.frameTicks = w.frameTicks[:0]
+	w.beginFrameTick()
+}
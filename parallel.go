@@ -0,0 +1,109 @@
+package ecs
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// 将[0, total)按batchSize分块并行执行，调用者所在的协程也参与执行，全部分块执行完后返回。
+// 辅助任务投递到调用者所在worker(from)的本地队列，不在协程池中时from为nil，投递到公共队列。
+// 协程池繁忙时由调用者独自完成，在协程池中的系统里调用也不会死锁。
+// 分块panic时记录第一个panic，全部分块结束后在调用者的协程中重新抛出，由系统的panic策略处理。
+// 确定性模式下由调用者顺序执行
+func (w *ecsWorld) parallelFor(from *Worker, total int, batchSize int, fn func(begin, end int)) {
+	if total <= 0 {
+		return
+	}
+	if batchSize <= 0 {
+		// 默认每个线程分到约4个分块，便于负载均衡
+		batchSize = total/(int(w.workPool.Size())*4) + 1
+	}
+	chunks := (total + batchSize - 1) / batchSize
+	if chunks == 1 || w.config.Deterministic {
+		fn(0, total)
+		return
+	}
+
+	next := int64(-1)
+	wg := &sync.WaitGroup{}
+	wg.Add(chunks)
+	once := sync.Once{}
+	var panicked any
+	chunk := func(c int) {
+		defer func() {
+			if r := recover(); r != nil {
+				once.Do(func() {
+					panicked = r
+				})
+			}
+			wg.Done()
+		}()
+		begin := c * batchSize
+		end := begin + batchSize
+		if end > total {
+			end = total
+		}
+		fn(begin, end)
+	}
+	work := func() {
+		for {
+			c := int(atomic.AddInt64(&next, 1))
+			if c >= chunks {
+				return
+			}
+			chunk(c)
+		}
+	}
+	helper := func(*Worker) {
+		work()
+	}
+	helpers := chunks - 1
+	if size := int(w.workPool.Size()); helpers > size {
+		helpers = size
+	}
+	for i := 0; i < helpers; i++ {
+		if !w.workPool.tryAdd(from, helper) {
+			break
+		}
+	}
+	work()
+	wg.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// ParallelForEach 将组件T的数据按batchSize分块，在协程池中并行执行fn，全部执行完后返回，batchSize<=0时自动分块。
+// fn会被并发调用，只能访问传入的组件；只读声明的组件传入的是副本
+func ParallelForEach[T ComponentObject](sys ISystem, batchSize int, fn func(com *T)) {
+	if sys.getState() == SystemStateInvalid || !sys.isExecuting() {
+		return
+	}
+	typ := GetType[T]()
+	r, ok := sys.GetRequirements()[typ]
+	if !ok {
+		return
+	}
+	world := sys.World().base()
+	c := world.getComponentSet(typ)
+	if c == nil {
+		return
+	}
+	set := c.(*ComponentSet[T])
+	data := set.data[:set.Len()]
+	readOnly := r.getPermission() == ComponentReadOnly
+	tick := world.currentTick()
+	world.parallelFor(sys.getWorker(), len(data), batchSize, func(begin, end int) {
+		var temp T
+		for i := begin; i < end; i++ {
+			if readOnly {
+				temp = data[i]
+				fn(&temp)
+			} else {
+				markChanged(unsafe.Pointer(&data[i]), tick)
+				fn(&data[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package ecs
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+type __parallel_Test_C_1 struct {
+	Component[__parallel_Test_C_1]
+	Value int
+}
+
+type __parallel_Test_C_2 struct {
+	Component[__parallel_Test_C_2]
+	Value int
+}
+
+type __parallel_Test_Shape_1 struct {
+	c1 *__parallel_Test_C_1
+	c2 *__parallel_Test_C_2
+}
+
+type __parallel_Test_S_1 struct {
+	System[__parallel_Test_S_1]
+	shape   *Shape[__parallel_Test_Shape_1]
+	visited int64
+	worker  *Worker
+}
+
+func (s *__parallel_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__parallel_Test_C_1{}, &ReadOnly[__parallel_Test_C_2]{})
+	s.shape = NewShape[__parallel_Test_Shape_1](si)
+	return nil
+}
+
+func (s *__parallel_Test_S_1) Update(event Event) {
+	s.worker = s.getWorker()
+	ParallelForEach[__parallel_Test_C_1](s, 16, func(c *__parallel_Test_C_1) {
+		c.Value++
+	})
+	s.shape.ParallelForEach(0, func(shape *__parallel_Test_Shape_1) {
+		shape.c1.Value += 10
+		atomic.AddInt64(&s.visited, 1)
+	})
+}
+
+func TestParallelForEach(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__parallel_Test_S_1](world)
+	world.Startup()
+
+	var entities []Entity
+	for i := 0; i < 1000; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__parallel_Test_C_1{})
+		if i%2 == 0 {
+			world.Add(e, &__parallel_Test_C_2{})
+		}
+		entities = append(entities, e)
+	}
+	world.Update()
+
+	s, _ := world.getSystem(TypeOf[__parallel_Test_S_1]())
+	sys := s.(*__parallel_Test_S_1)
+	if sys.worker == nil {
+		t.Error("system executed in the pool should know its worker")
+	}
+	if sys.getWorker() != nil {
+		t.Error("worker should be cleared after execution")
+	}
+	if sys.visited != 500 {
+		t.Errorf("shape visited want 500, got %d", sys.visited)
+	}
+	set := world.getComponentSet(TypeOf[__parallel_Test_C_1]()).(*ComponentSet[__parallel_Test_C_1])
+	for i, e := range entities {
+		want := 1
+		if i%2 == 0 {
+			want = 11
+		}
+		if c := set.Get(e); c == nil || c.Value != want {
+			t.Fatalf("entity %d value want %d, got %+v", e, want, c)
+		}
+	}
+	world.Stop()
+}
+
+// 分块panic时其他分块照常执行，panic在调用者的协程中重新抛出
+func TestParallelFor_Panic(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	world.Startup()
+	defer world.Stop()
+
+	var executed int64
+	func() {
+		defer func() {
+			if r := recover(); r != "chunk failed" {
+				t.Errorf("want panic rethrown, got %v", r)
+			}
+		}()
+		world.parallelFor(nil, 100, 10, func(begin, end int) {
+			atomic.AddInt64(&executed, 1)
+			if begin == 30 {
+				panic("chunk failed")
+			}
+		})
+	}()
+	if executed != 10 {
+		t.Errorf("chunks executed want 10, got %d", executed)
+	}
+}
@@ -0,0 +1,177 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Prefab 实体模板，Components为组件的初始值，Base为继承的模板名，
+// 与Base中同类型的组件整体覆盖Base中的组件
+type Prefab struct {
+	Name       string
+	Base       string
+	Components []IComponent
+}
+
+// NewPrefab 创建模板，可以继续通过Inherit指定继承的模板
+func NewPrefab(name string, components ...IComponent) *Prefab {
+	return &Prefab{Name: name, Components: components}
+}
+
+// Inherit 继承模板base，base可以在之后注册，生成实体时才解析
+func (p *Prefab) Inherit(base string) *Prefab {
+	p.Base = base
+	return p
+}
+
+type jsonPrefab struct {
+	Name       string          `json:"name"`
+	Base       string          `json:"base,omitempty"`
+	Components []jsonComponent `json:"components"`
+}
+
+// 模板注册表，可能在AsyncWorld的主循环外注册，需加锁
+type prefabRegistry struct {
+	lock    sync.RWMutex
+	prefabs map[string]*Prefab
+}
+
+// 注册或替换模板，模板中的组件会被复制，注册后修改原组件不影响模板
+func (w *ecsWorld) registerPrefab(prefab *Prefab) error {
+	p, err := clonePrefab(prefab)
+	if err != nil {
+		return err
+	}
+	w.storePrefabs(p)
+	return nil
+}
+
+func (w *ecsWorld) storePrefabs(prefabs ...*Prefab) {
+	w.prefabs.lock.Lock()
+	defer w.prefabs.lock.Unlock()
+	if w.prefabs.prefabs == nil {
+		w.prefabs.prefabs = map[string]*Prefab{}
+	}
+	for _, p := range prefabs {
+		w.prefabs.prefabs[p.Name] = p
+	}
+}
+
+// 检查并复制模板，同一模板中重复的组件类型以后出现的为准
+func clonePrefab(prefab *Prefab) (*Prefab, error) {
+	if prefab == nil || prefab.Name == "" {
+		return nil, fmt.Errorf("prefab name is empty")
+	}
+	p := &Prefab{Name: prefab.Name, Base: prefab.Base, Components: make([]IComponent, 0, len(prefab.Components))}
+	for _, com := range prefab.Components {
+		if com == nil {
+			return nil, fmt.Errorf("prefab %s has nil component", prefab.Name)
+		}
+		if com.getComponentType()&ComponentTypeFreeMask > 0 {
+			return nil, fmt.Errorf("prefab %s: free component %s can not be used in prefab", prefab.Name, com.Type().Name())
+		}
+		p.Components = overrideComponents(p.Components, []IComponent{copyComponent(com)})
+	}
+	return p, nil
+}
+
+// 从JSON数组加载模板，组件格式与ExportJSON相同，组件类型需已在世界中注册，任一模板解析失败时不注册任何模板
+func (w *ecsWorld) loadPrefabs(in io.Reader) error {
+	var docs []jsonPrefab
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&docs); err != nil {
+		return err
+	}
+
+	known := w.knownComponentTypes()
+	prefabs := make([]*Prefab, 0, len(docs))
+	for _, doc := range docs {
+		prefab := &Prefab{Name: doc.Name, Base: doc.Base}
+		for _, c := range doc.Components {
+			com, err := decodeJSONComponent(known, c)
+			if err != nil {
+				return fmt.Errorf("prefab %s: %w", doc.Name, err)
+			}
+			prefab.Components = append(prefab.Components, com)
+		}
+		p, err := clonePrefab(prefab)
+		if err != nil {
+			return err
+		}
+		prefabs = append(prefabs, p)
+	}
+	w.storePrefabs(prefabs...)
+	return nil
+}
+
+// 沿继承链合并模板的组件，基础模板在前
+func (w *ecsWorld) resolvePrefab(name string) ([]IComponent, error) {
+	w.prefabs.lock.RLock()
+	defer w.prefabs.lock.RUnlock()
+
+	var chain []*Prefab
+	for n := name; n != ""; {
+		p, ok := w.prefabs.prefabs[n]
+		if !ok {
+			return nil, fmt.Errorf("prefab %s is not registered", n)
+		}
+		for _, c := range chain {
+			if c == p {
+				return nil, fmt.Errorf("prefab %s has circular inheritance", name)
+			}
+		}
+		chain = append(chain, p)
+		n = p.Base
+	}
+
+	var components []IComponent
+	for i := len(chain) - 1; i >= 0; i-- {
+		components = overrideComponents(components, chain[i].Components)
+	}
+	return components, nil
+}
+
+// 生成实体需要的组件副本，overrides整体覆盖模板中同类型的组件
+func instantiatePrefab(components []IComponent, overrides []IComponent) []IComponent {
+	merged := overrideComponents(append([]IComponent(nil), components...), overrides)
+	for i, com := range merged {
+		merged[i] = copyComponent(com)
+	}
+	return merged
+}
+
+func overrideComponents(components []IComponent, overrides []IComponent) []IComponent {
+	for _, o := range overrides {
+		replaced := false
+		for i, c := range components {
+			if c.Type() == o.Type() {
+				components[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			components = append(components, o)
+		}
+	}
+	return components
+}
+
+// 组件均为纯值类型，浅拷贝即可得到独立的副本
+func copyComponent(com IComponent) IComponent {
+	v := reflect.New(com.Type())
+	v.Elem().Set(reflect.ValueOf(com).Elem())
+	return v.Interface().(IComponent)
+}
+
+func (w *ecsWorld) knownComponentTypes() map[string]reflect.Type {
+	known := map[string]reflect.Type{}
+	for typ := range w.componentMeta.types {
+		known[componentTypeName(typ)] = typ
+	}
+	return known
+}
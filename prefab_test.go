@@ -0,0 +1,101 @@
+package ecs
+
+import (
+	"strings"
+	"testing"
+)
+
+type __prefab_Test_C_1 struct {
+	Component[__prefab_Test_C_1]
+	Field1 int
+}
+
+type __prefab_Test_C_2 struct {
+	Component[__prefab_Test_C_2]
+	Field1 int
+}
+
+type __prefab_Test_S_1 struct {
+	System[__prefab_Test_S_1]
+}
+
+func (s *__prefab_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__prefab_Test_C_1{}, &__prefab_Test_C_2{})
+	return nil
+}
+
+func TestPrefab(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__prefab_Test_S_1](world)
+	world.Startup()
+	sys, _ := world.getSystem(TypeOf[__prefab_Test_S_1]())
+
+	check := func(name string, entity Entity, f1, f2 int) {
+		t.Helper()
+		c1 := GetRelated[__prefab_Test_C_1](sys, entity)
+		c2 := GetRelated[__prefab_Test_C_2](sys, entity)
+		if c1 == nil || c2 == nil || c1.Field1 != f1 || c2.Field1 != f2 {
+			t.Errorf("%s want (%d, %d), got (%v, %v)", name, f1, f2, c1, c2)
+		}
+	}
+
+	template := &__prefab_Test_C_1{Field1: 1}
+	if err := world.RegisterPrefab(NewPrefab("monster", template, &__prefab_Test_C_2{Field1: 10})); err != nil {
+		t.Fatal(err)
+	}
+	if err := world.RegisterPrefab(NewPrefab("goblin", &__prefab_Test_C_2{Field1: 20}).Inherit("monster")); err != nil {
+		t.Fatal(err)
+	}
+	// 注册后修改原组件不影响模板
+	template.Field1 = 100
+
+	goblin, err := world.Spawn("goblin", &__prefab_Test_C_1{Field1: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	monsters, err := world.SpawnN("monster", 3)
+	if err != nil || len(monsters) != 3 {
+		t.Fatalf("spawn monsters failed, %v", err)
+	}
+	world.Update()
+	check("goblin", goblin, 5, 20)
+	GetRelated[__prefab_Test_C_1](sys, monsters[0]).Field1 = 2
+	for _, monster := range monsters[1:] {
+		check("monster", monster, 1, 10)
+	}
+
+	// JSON中的模板可以继承Go代码注册的模板
+	doc := `[
+		{"name": "orc", "base": "monster", "components": [{"type": "ecs.__prefab_Test_C_2", "value": {"Field1": 30}}]},
+		{"name": "orc_chief", "base": "orc", "components": [{"type": "ecs.__prefab_Test_C_1", "value": {"Field1": 3}}]}
+	]`
+	if err = world.LoadPrefabs(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	chief, err := world.Spawn("orc_chief")
+	if err != nil {
+		t.Fatal(err)
+	}
+	world.Update()
+	check("orc chief", chief, 3, 30)
+
+	if _, err = world.Spawn("dragon"); err == nil {
+		t.Error("spawn unknown prefab should fail")
+	}
+	_ = world.RegisterPrefab(NewPrefab("a").Inherit("b"))
+	_ = world.RegisterPrefab(NewPrefab("b").Inherit("a"))
+	if _, err = world.Spawn("a"); err == nil {
+		t.Error("circular inheritance should fail")
+	}
+	bad := `[{"name": "bad", "components": [{"type": "ecs.__prefab_Test_C_3"}]}, {"name": "good", "components": []}]`
+	if err = world.LoadPrefabs(strings.NewReader(bad)); err == nil {
+		t.Error("unknown component should fail")
+	}
+	if _, err = world.Spawn("good"); err == nil {
+		t.Error("prefabs should not be registered when loading fails")
+	}
+	world.Stop()
+}
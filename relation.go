@@ -0,0 +1,345 @@
+package ecs
+
+import "reflect"
+
+type iRelationStore interface {
+	hasTargets(entity Entity) bool
+	targetsOf(entity Entity) []Entity
+	sourcesOf(entity Entity) []Entity
+	removeEntity(entity Entity)
+	saveState() any
+	loadState(state any)
+}
+
+type relationPair[R any] struct {
+	target Entity
+	data   R
+}
+
+// 关系R的双向索引，source -> (target, 数据)，target -> source，均按建立顺序排列。
+// 只在同步点修改，系统执行期间只读
+type relationStore[R any] struct {
+	targets map[Entity][]relationPair[R]
+	sources map[Entity][]Entity
+	saved   *relationStore[R] // 最近一次保存的副本，修改后失效
+}
+
+func newRelationStore[R any]() *relationStore[R] {
+	return &relationStore[R]{
+		targets: map[Entity][]relationPair[R]{},
+		sources: map[Entity][]Entity{},
+	}
+}
+
+func (s *relationStore[R]) hasTargets(entity Entity) bool {
+	return len(s.targets[entity]) > 0
+}
+
+func (s *relationStore[R]) targetsOf(entity Entity) []Entity {
+	pairs := s.targets[entity]
+	if len(pairs) == 0 {
+		return nil
+	}
+	targets := make([]Entity, len(pairs))
+	for i, pair := range pairs {
+		targets[i] = pair.target
+	}
+	return targets
+}
+
+func (s *relationStore[R]) sourcesOf(entity Entity) []Entity {
+	sources := s.sources[entity]
+	if len(sources) == 0 {
+		return nil
+	}
+	return append([]Entity(nil), sources...)
+}
+
+func (s *relationStore[R]) get(source Entity, target Entity) (R, bool) {
+	for _, pair := range s.targets[source] {
+		if pair.target == target {
+			return pair.data, true
+		}
+	}
+	var zero R
+	return zero, false
+}
+
+// 已存在时只替换数据
+func (s *relationStore[R]) set(source Entity, target Entity, data R) {
+	s.saved = nil
+	pairs := s.targets[source]
+	for i := range pairs {
+		if pairs[i].target == target {
+			pairs[i].data = data
+			return
+		}
+	}
+	s.targets[source] = append(pairs, relationPair[R]{target: target, data: data})
+	s.sources[target] = append(s.sources[target], source)
+}
+
+func (s *relationStore[R]) unset(source Entity, target Entity) {
+	pairs := s.targets[source]
+	for i := range pairs {
+		if pairs[i].target == target {
+			s.saved = nil
+			s.setTargets(source, append(pairs[:i], pairs[i+1:]...))
+			s.setSources(target, removeEntityFrom(s.sources[target], source))
+			return
+		}
+	}
+}
+
+// 移除实体作为source和target的全部关系
+func (s *relationStore[R]) removeEntity(entity Entity) {
+	if len(s.targets[entity]) == 0 && len(s.sources[entity]) == 0 {
+		return
+	}
+	s.saved = nil
+	for _, pair := range s.targets[entity] {
+		s.setSources(pair.target, removeEntityFrom(s.sources[pair.target], entity))
+	}
+	delete(s.targets, entity)
+	for _, source := range s.sources[entity] {
+		pairs := s.targets[source]
+		for i := range pairs {
+			if pairs[i].target == entity {
+				s.setTargets(source, append(pairs[:i], pairs[i+1:]...))
+				break
+			}
+		}
+	}
+	delete(s.sources, entity)
+}
+
+func (s *relationStore[R]) setTargets(source Entity, pairs []relationPair[R]) {
+	if len(pairs) == 0 {
+		delete(s.targets, source)
+		return
+	}
+	s.targets[source] = pairs
+}
+
+func (s *relationStore[R]) setSources(target Entity, sources []Entity) {
+	if len(sources) == 0 {
+		delete(s.sources, target)
+		return
+	}
+	s.sources[target] = sources
+}
+
+func removeEntityFrom(entities []Entity, entity Entity) []Entity {
+	for i, e := range entities {
+		if e == entity {
+			return append(entities[:i], entities[i+1:]...)
+		}
+	}
+	return entities
+}
+
+// 关系变更不频繁，变更后第一次保存时复制，未变更时各帧共享同一副本，副本保存后不再修改
+func (s *relationStore[R]) saveState() any {
+	if s.saved != nil {
+		return s.saved
+	}
+	state := &relationStore[R]{}
+	state.targets = make(map[Entity][]relationPair[R], len(s.targets))
+	state.sources = make(map[Entity][]Entity, len(s.sources))
+	for source, pairs := range s.targets {
+		state.targets[source] = append([]relationPair[R](nil), pairs...)
+	}
+	for target, sources := range s.sources {
+		state.sources[target] = append([]Entity(nil), sources...)
+	}
+	s.saved = state
+	return state
+}
+
+func (s *relationStore[R]) loadState(state any) {
+	s.targets = map[Entity][]relationPair[R]{}
+	s.sources = map[Entity][]Entity{}
+	saved, _ := state.(*relationStore[R])
+	s.saved = saved
+	if saved == nil {
+		return
+	}
+	for source, pairs := range saved.targets {
+		s.targets[source] = append([]relationPair[R](nil), pairs...)
+	}
+	for target, sources := range saved.sources {
+		s.sources[target] = append([]Entity(nil), sources...)
+	}
+}
+
+// 系统并行执行时可能同时创建，需加锁
+func getRelationStore[R any](w *ecsWorld) iRelationStore {
+	typ := TypeOf[R]()
+	w.relationLock.Lock()
+	defer w.relationLock.Unlock()
+	store, ok := w.relations[typ]
+	if !ok {
+		if !IsPureValueType(typ) {
+			panic("relation must be pure value type")
+		}
+		store = newRelationStore[R]()
+		w.relations[typ] = store
+	}
+	return store
+}
+
+func (w *ecsWorld) pushRelation(op func()) {
+	w.relationLock.Lock()
+	w.relationOps = append(w.relationOps, op)
+	w.relationLock.Unlock()
+}
+
+// Relate 建立source到target的关系R，已存在时替换关系数据，在下一个同步点生效。
+// R为纯值类型，可以是空结构体，同一实体可以对多个target建立同一种关系
+func Relate[R any](world IWorld, source Entity, target Entity, relation R) {
+	w := world.base()
+	store := getRelationStore[R](w).(*relationStore[R])
+	w.pushRelation(func() {
+		if !w.IsAlive(source) || !w.IsAlive(target) {
+			Log.Errorf("relate %d to %d failed, entity is not alive", source, target)
+			return
+		}
+		store.set(source, target, relation)
+	})
+}
+
+// Unrelate 解除source到target的关系R，在下一个同步点生效
+func Unrelate[R any](world IWorld, source Entity, target Entity) {
+	w := world.base()
+	store := getRelationStore[R](w).(*relationStore[R])
+	w.pushRelation(func() {
+		store.unset(source, target)
+	})
+}
+
+// GetRelation source到target的关系R的数据
+func GetRelation[R any](sys ISystem, source Entity, target Entity) (R, bool) {
+	return getRelationStore[R](sys.World().base()).(*relationStore[R]).get(source, target)
+}
+
+// GetTargets source通过关系R指向的全部实体，按建立顺序排列
+func GetTargets[R any](sys ISystem, source Entity) []Entity {
+	return getRelationStore[R](sys.World().base()).targetsOf(source)
+}
+
+// GetSources 通过关系R指向target的全部实体，按建立顺序排列
+func GetSources[R any](sys ISystem, target Entity) []Entity {
+	return getRelationStore[R](sys.World().base()).sourcesOf(target)
+}
+
+// WithRelation 实体必须通过关系R指向至少一个实体
+func WithRelation[R any]() ShapeFilter {
+	return ShapeFilter{relation: getRelationStore[R]}
+}
+
+// WithoutRelation 实体不能通过关系R指向任何实体
+func WithoutRelation[R any]() ShapeFilter {
+	return ShapeFilter{relation: getRelationStore[R], kind: shapeFilterWithout}
+}
+
+type relationFilter struct {
+	store   iRelationStore
+	without bool
+}
+
+// RelationSources 遍历通过关系R指向target且满足shape条件的实体
+func RelationSources[R any, T any](shape *Shape[T], target Entity) IShapeIterator[T] {
+	shape.executeNum++
+	return &relationShapeIter[T]{
+		shape:    shape,
+		entities: getRelationStore[R](shape.sys.World().base()).sourcesOf(target),
+	}
+}
+
+// RelationTargets 遍历source通过关系R指向的、满足shape条件的实体
+func RelationTargets[R any, T any](shape *Shape[T], source Entity) IShapeIterator[T] {
+	shape.executeNum++
+	return &relationShapeIter[T]{
+		shape:    shape,
+		entities: getRelationStore[R](shape.sys.World().base()).targetsOf(source),
+	}
+}
+
+type relationShapeIter[T any] struct {
+	shape    *Shape[T]
+	entities []Entity
+	offset   int
+	cur      *T
+}
+
+func (s *relationShapeIter[T]) tryNext() *T {
+	s.cur = nil
+	for ; s.offset < len(s.entities); s.offset++ {
+		if cur, ok := s.shape.GetSpecific(s.entities[s.offset]); ok {
+			s.cur = cur
+			break
+		}
+	}
+	return s.cur
+}
+
+func (s *relationShapeIter[T]) Begin() *T {
+	s.offset = 0
+	return s.tryNext()
+}
+
+func (s *relationShapeIter[T]) Val() *T {
+	return s.cur
+}
+
+func (s *relationShapeIter[T]) Next() *T {
+	s.offset++
+	return s.tryNext()
+}
+
+func (s *relationShapeIter[T]) End() bool {
+	return s.cur == nil
+}
+
+// 同步点，应用关系的变更
+func (w *ecsWorld) applyRelations() {
+	w.relationLock.Lock()
+	ops := w.relationOps
+	w.relationOps = nil
+	w.relationLock.Unlock()
+	for _, op := range ops {
+		op()
+	}
+}
+
+// 同步点，清理与销毁的实体相关的全部关系，不回收ID（由freeEntities回收）
+func (w *ecsWorld) releaseEntities() {
+	for _, entity := range w.released {
+		for _, store := range w.relations {
+			store.removeEntity(entity)
+		}
+	}
+}
+
+func (w *ecsWorld) saveRelations(states map[reflect.Type]any) {
+	for typ, store := range w.relations {
+		states[typ] = store.saveState()
+	}
+}
+
+func (w *ecsWorld) loadRelations(states map[reflect.Type]any) {
+	w.relationOps = nil
+	w.released = w.released[:0]
+	for typ, store := range w.relations {
+		store.loadState(states[typ])
+	}
+}
+
+// 快照替换了全部实体，原有关系全部失效
+func (w *ecsWorld) clearRelations() {
+	w.relationOps = nil
+	w.released = w.released[:0]
+	for _, store := range w.relations {
+		store.loadState(nil)
+	}
+}
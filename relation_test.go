@@ -0,0 +1,144 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type __relation_Test_Likes struct{}
+
+type __relation_Test_Aggro struct {
+	Value int
+}
+
+type __relation_Test_C_1 struct {
+	Component[__relation_Test_C_1]
+	Field1 int
+}
+
+type __relation_Test_Shape_1 struct {
+	C1 *__relation_Test_C_1
+}
+
+type __relation_Test_S_1 struct {
+	System[__relation_Test_S_1]
+	likes    *Shape[__relation_Test_Shape_1]
+	lonely   *Shape[__relation_Test_Shape_1]
+	relation *Shape[__relation_Test_Shape_1]
+}
+
+func (s *__relation_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__relation_Test_C_1{})
+	s.likes = NewShape[__relation_Test_Shape_1](si, WithRelation[__relation_Test_Likes]())
+	s.lonely = NewShape[__relation_Test_Shape_1](si, WithoutRelation[__relation_Test_Likes]())
+	s.relation = NewShape[__relation_Test_Shape_1](si)
+	return nil
+}
+
+func TestRelation(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__relation_Test_S_1](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__relation_Test_S_1]())
+	sys := s.(*__relation_Test_S_1)
+
+	var entities []Entity
+	for i := 0; i < 4; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__relation_Test_C_1{Field1: i})
+		entities = append(entities, e)
+	}
+	a, b, c, target := entities[0], entities[1], entities[2], entities[3]
+	Relate(world, a, target, __relation_Test_Likes{})
+	Relate(world, b, target, __relation_Test_Likes{})
+	Relate(world, a, c, __relation_Test_Likes{})
+	Relate(world, a, target, __relation_Test_Aggro{Value: 10})
+	Relate(world, a, target, __relation_Test_Aggro{Value: 30})
+	world.Update()
+
+	check := func(name string, got, want any) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s want %v, got %v", name, want, got)
+		}
+	}
+	collect := func(iter IShapeIterator[__relation_Test_Shape_1]) []int {
+		var fields []int
+		for shape := iter.Begin(); !iter.End(); shape = iter.Next() {
+			fields = append(fields, shape.C1.Field1)
+		}
+		return fields
+	}
+
+	check("sources", GetSources[__relation_Test_Likes](sys, target), []Entity{a, b})
+	check("targets", GetTargets[__relation_Test_Likes](sys, a), []Entity{target, c})
+	aggro, ok := GetRelation[__relation_Test_Aggro](sys, a, target)
+	check("aggro", aggro.Value, 30)
+	if _, ok = GetRelation[__relation_Test_Aggro](sys, b, target); ok {
+		t.Error("b has no aggro relation")
+	}
+	check("with relation", collect(sys.likes.Get()), []int{0, 1})
+	check("without relation", collect(sys.lonely.Get()), []int{2, 3})
+	check("relation sources", collect(RelationSources[__relation_Test_Likes](sys.relation, target)), []int{0, 1})
+	check("relation targets", collect(RelationTargets[__relation_Test_Likes](sys.likes, a)), []int(nil))
+	check("relation targets", collect(RelationTargets[__relation_Test_Likes](sys.relation, a)), []int{3, 2})
+
+	Unrelate[__relation_Test_Likes](world, b, target)
+	world.Update()
+	check("unrelate", GetSources[__relation_Test_Likes](sys, target), []Entity{a})
+
+	// 销毁的实体在ID回收时清理相关的关系，复用索引的新实体不会继承
+	world.DestroyEntity(target)
+	for i := 0; i < 16; i++ {
+		world.DestroyEntity(world.NewEntity())
+	}
+	world.Update()
+	check("destroyed target", GetTargets[__relation_Test_Likes](sys, a), []Entity{c})
+	if _, ok = GetRelation[__relation_Test_Aggro](sys, a, target); ok {
+		t.Error("aggro to destroyed entity should be removed")
+	}
+	reused := false
+	for i := 0; i < 16; i++ {
+		e := world.NewEntity()
+		if e.ToRealID().index == target.ToRealID().index {
+			reused = true
+			if e == target {
+				t.Error("reused entity should have a new reuse counter")
+			}
+			check("reused sources", GetSources[__relation_Test_Likes](sys, e), []Entity(nil))
+		}
+	}
+	if !reused {
+		t.Error("index of destroyed entity should be recycled")
+	}
+	world.Stop()
+}
+
+// 关系未变更时各帧共享同一份保存的副本
+func TestRelation_SaveState(t *testing.T) {
+	store := newRelationStore[__relation_Test_Likes]()
+	store.set(1, 2, __relation_Test_Likes{})
+	first := store.saveState()
+	if store.saveState() != first {
+		t.Error("unchanged store should reuse the saved state")
+	}
+	store.removeEntity(3)
+	if store.saveState() != first {
+		t.Error("removing an unrelated entity should not invalidate the saved state")
+	}
+	store.set(1, 3, __relation_Test_Likes{})
+	second := store.saveState()
+	if second == first {
+		t.Error("changed store should save a new state")
+	}
+	store.loadState(first)
+	if got := store.targetsOf(1); !reflect.DeepEqual(got, []Entity{2}) {
+		t.Errorf("targets want [2], got %v", got)
+	}
+	if store.saveState() != first {
+		t.Error("loaded state should be reused")
+	}
+}
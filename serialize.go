@@ -1,7 +1,265 @@
 package ecs
 
-// TODO 世界的序列化、反序列化
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// ICustomSerialize 组件实现该接口后，世界快照使用该接口代替默认的反射编码
 type ICustomSerialize interface {
 	Serialize() []byte
 	DeSerialize(b []byte)
 }
+
+var (
+	ErrSnapshotInvalid = errors.New("invalid snapshot data")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	// ErrDiffLocalEntities 世界在本地创建或销毁过实体，不能再应用增量
+	ErrDiffLocalEntities = errors.New("world created or destroyed entities locally, diff can not be applied")
+)
+
+var customSerializeType = TypeOf[ICustomSerialize]()
+
+type binaryWriter struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func newBinaryWriter(w io.Writer) *binaryWriter {
+	return &binaryWriter{w: bufio.NewWriter(w)}
+}
+
+func (b *binaryWriter) write(p []byte) {
+	if b.err != nil {
+		return
+	}
+	_, b.err = b.w.Write(p)
+}
+
+func (b *binaryWriter) writeUint8(v uint8) {
+	b.buf[0] = v
+	b.write(b.buf[:1])
+}
+
+func (b *binaryWriter) writeUint16(v uint16) {
+	binary.LittleEndian.PutUint16(b.buf[:2], v)
+	b.write(b.buf[:2])
+}
+
+func (b *binaryWriter) writeUint32(v uint32) {
+	binary.LittleEndian.PutUint32(b.buf[:4], v)
+	b.write(b.buf[:4])
+}
+
+func (b *binaryWriter) writeUint64(v uint64) {
+	binary.LittleEndian.PutUint64(b.buf[:8], v)
+	b.write(b.buf[:8])
+}
+
+func (b *binaryWriter) writeBytes(p []byte) {
+	b.writeUint32(uint32(len(p)))
+	b.write(p)
+}
+
+func (b *binaryWriter) writeString(s string) {
+	b.writeBytes([]byte(s))
+}
+
+func (b *binaryWriter) flush() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.w.Flush()
+}
+
+type binaryReader struct {
+	r   *bufio.Reader
+	buf [8]byte
+	err error
+}
+
+func newBinaryReader(r io.Reader) *binaryReader {
+	return &binaryReader{r: bufio.NewReader(r)}
+}
+
+func (b *binaryReader) read(p []byte) {
+	if b.err != nil {
+		return
+	}
+	if _, err := io.ReadFull(b.r, p); err != nil {
+		b.err = ErrSnapshotInvalid
+	}
+}
+
+func (b *binaryReader) readUint8() uint8 {
+	b.read(b.buf[:1])
+	return b.buf[0]
+}
+
+func (b *binaryReader) readUint16() uint16 {
+	b.read(b.buf[:2])
+	return binary.LittleEndian.Uint16(b.buf[:2])
+}
+
+func (b *binaryReader) readUint32() uint32 {
+	b.read(b.buf[:4])
+	return binary.LittleEndian.Uint32(b.buf[:4])
+}
+
+func (b *binaryReader) readUint64() uint64 {
+	b.read(b.buf[:8])
+	return binary.LittleEndian.Uint64(b.buf[:8])
+}
+
+// 单个字节串（字符串、自定义序列化的组件）的长度上限
+const maxReadBytes = 64 << 20
+
+// 长度来自输入数据，不可信，按实际读到的数据扩容，数据不足时不会预先分配n字节
+func (b *binaryReader) readBytes() []byte {
+	n := b.readUint32()
+	if b.err != nil {
+		return nil
+	}
+	if n > maxReadBytes {
+		b.err = ErrSnapshotInvalid
+		return nil
+	}
+	buf := bytes.Buffer{}
+	if n <= bytes.MinRead {
+		buf.Grow(int(n))
+	}
+	if _, err := io.CopyN(&buf, b.r, int64(n)); err != nil {
+		b.err = ErrSnapshotInvalid
+		return nil
+	}
+	return buf.Bytes()
+}
+
+func (b *binaryReader) readString() string {
+	return string(b.readBytes())
+}
+
+// 组件的类型名，用于在不同进程的世界间匹配组件类型
+func componentTypeName(typ reflect.Type) string {
+	return typ.String()
+}
+
+func isCustomSerialize(typ reflect.Type) bool {
+	return reflect.PointerTo(typ).Implements(customSerializeType)
+}
+
+// 组件数据编码，跳过组件头（第一个字段），其余字段按IsPureValueType规则编码
+func encodeComponent(b *binaryWriter, com IComponent) {
+	if cs, ok := com.(ICustomSerialize); ok {
+		b.writeBytes(cs.Serialize())
+		return
+	}
+	typ := com.Type()
+	p := com.debugAddress()
+	for i := 1; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		encodePureValue(b, field.Type, unsafe.Add(p, field.Offset))
+	}
+}
+
+// 创建typ类型的组件实例并解码数据
+func decodeComponent(b *binaryReader, typ reflect.Type) IComponent {
+	com := reflect.New(typ).Interface().(IComponent)
+	if cs, ok := com.(ICustomSerialize); ok {
+		data := b.readBytes()
+		if b.err == nil {
+			cs.DeSerialize(data)
+		}
+		return com
+	}
+	p := com.debugAddress()
+	for i := 1; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		decodePureValue(b, field.Type, unsafe.Add(p, field.Offset))
+	}
+	return com
+}
+
+func encodePureValue(b *binaryWriter, typ reflect.Type, p unsafe.Pointer) {
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		b.writeUint8(*(*uint8)(p))
+	case reflect.Int16, reflect.Uint16:
+		b.writeUint16(*(*uint16)(p))
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		b.writeUint32(*(*uint32)(p))
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		b.writeUint64(*(*uint64)(p))
+	case reflect.Int:
+		b.writeUint64(uint64(*(*int)(p)))
+	case reflect.Uint:
+		b.writeUint64(uint64(*(*uint)(p)))
+	case reflect.Array:
+		elem := typ.Elem()
+		if elem.Kind() == reflect.Uint8 || elem.Kind() == reflect.Int8 {
+			b.write(unsafe.Slice((*byte)(p), typ.Len()))
+			return
+		}
+		for i := 0; i < typ.Len(); i++ {
+			encodePureValue(b, elem, unsafe.Add(p, uintptr(i)*elem.Size()))
+		}
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			encodePureValue(b, field.Type, unsafe.Add(p, field.Offset))
+		}
+	default:
+		if b.err == nil {
+			b.err = fmt.Errorf("can not encode non pure value type %s", typ.String())
+		}
+	}
+}
+
+func decodePureValue(b *binaryReader, typ reflect.Type, p unsafe.Pointer) {
+	switch typ.Kind() {
+	case reflect.Bool:
+		*(*bool)(p) = b.readUint8() != 0
+	case reflect.Int8, reflect.Uint8:
+		*(*uint8)(p) = b.readUint8()
+	case reflect.Int16, reflect.Uint16:
+		*(*uint16)(p) = b.readUint16()
+	case reflect.Int32, reflect.Uint32:
+		*(*uint32)(p) = b.readUint32()
+	case reflect.Float32:
+		*(*float32)(p) = math.Float32frombits(b.readUint32())
+	case reflect.Int64, reflect.Uint64:
+		*(*uint64)(p) = b.readUint64()
+	case reflect.Float64:
+		*(*float64)(p) = math.Float64frombits(b.readUint64())
+	case reflect.Int:
+		*(*int)(p) = int(int64(b.readUint64()))
+	case reflect.Uint:
+		*(*uint)(p) = uint(b.readUint64())
+	case reflect.Array:
+		elem := typ.Elem()
+		if elem.Kind() == reflect.Uint8 || elem.Kind() == reflect.Int8 {
+			b.read(unsafe.Slice((*byte)(p), typ.Len()))
+			return
+		}
+		for i := 0; i < typ.Len(); i++ {
+			decodePureValue(b, elem, unsafe.Add(p, uintptr(i)*elem.Size()))
+		}
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			decodePureValue(b, field.Type, unsafe.Add(p, field.Offset))
+		}
+	default:
+		if b.err == nil {
+			b.err = fmt.Errorf("can not decode non pure value type %s", typ.String())
+		}
+	}
+}
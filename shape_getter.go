@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"fmt"
 	"reflect"
 	"unsafe"
 )
@@ -28,32 +29,96 @@ func (s *shapeBase) init(typ reflect.Type, getter IShape) {
 }
 
 type ShapeIndices struct {
-	subTypes   []uint16
-	subOffset  []uintptr
-	containers []IComponentSet
-	readOnly   []bool
+	subTypes         []uint16
+	subOffset        []uintptr
+	containers       []IComponentSet
+	readOnly         []bool
+	subKinds         []shapeFilterKind
+	filterContainers []IComponentSet
+	filterKinds      []shapeFilterKind
+	relations        []relationFilter
+	since            uint32
+	tick             uint32
+}
+
+type shapeFilterKind uint8
+
+const (
+	shapeFilterNone shapeFilterKind = iota
+	shapeFilterWith
+	shapeFilterWithout
+	shapeFilterOptional
+	shapeFilterChanged
+	shapeFilterAdded
+)
+
+var shapeFilterTags = map[string]shapeFilterKind{
+	"with":     shapeFilterWith,
+	"without":  shapeFilterWithout,
+	"optional": shapeFilterOptional,
+	"changed":  shapeFilterChanged,
+	"added":    shapeFilterAdded,
+}
+
+// ShapeFilter Shape的过滤条件，也可以在Shape结构体的组件指针字段上使用标签 ecs:"with|without|optional|changed|added"
+type ShapeFilter struct {
+	com      IComponent
+	kind     shapeFilterKind
+	relation func(w *ecsWorld) iRelationStore
+}
+
+// With 实体必须拥有组件T，仅作为过滤条件，不获取组件数据，不需要在系统中声明
+func With[T ComponentObject, TP ComponentPointer[T]]() ShapeFilter {
+	return ShapeFilter{com: TP(new(T)), kind: shapeFilterWith}
+}
+
+// Without 实体不能拥有组件T，不需要在系统中声明
+func Without[T ComponentObject, TP ComponentPointer[T]]() ShapeFilter {
+	return ShapeFilter{com: TP(new(T)), kind: shapeFilterWithout}
+}
+
+// Optional Shape中类型为*T的字段为可选字段，实体没有该组件时字段为nil
+func Optional[T ComponentObject, TP ComponentPointer[T]]() ShapeFilter {
+	return ShapeFilter{com: TP(new(T)), kind: shapeFilterOptional}
+}
+
+// Changed 组件T在系统上次执行后被写访问过，包括新添加的组件
+func Changed[T ComponentObject, TP ComponentPointer[T]]() ShapeFilter {
+	return ShapeFilter{com: TP(new(T)), kind: shapeFilterChanged}
+}
+
+// Added 组件T在系统上次执行后被添加
+func Added[T ComponentObject, TP ComponentPointer[T]]() ShapeFilter {
+	return ShapeFilter{com: TP(new(T)), kind: shapeFilterAdded}
 }
 
 type Shape[T any] struct {
 	shapeBase
-	initializer  SystemInitConstraint
-	mainKeyIndex int
-	subTypes     []uint16
-	subOffset    []uintptr
-	containers   []IComponentSet
-	readOnly     []bool
-	cur          *T
-	valid        bool
+	initializer      SystemInitConstraint
+	mainKeyIndex     int
+	subTypes         []uint16
+	subOffset        []uintptr
+	containers       []IComponentSet
+	readOnly         []bool
+	subKinds         []shapeFilterKind
+	filterTypes      []uint16
+	filterContainers []IComponentSet
+	filterKinds      []shapeFilterKind
+	relations        []relationFilter
+	siblings         []unsafe.Pointer
+	cur              *T
+	valid            bool
 }
 
-func NewShape[T any](initializer SystemInitConstraint) *Shape[T] {
+func NewShape[T any](initializer SystemInitConstraint, filters ...ShapeFilter) *Shape[T] {
 	if initializer.isValid() {
 		panic("out of initialization stage")
 	}
 	sys := initializer.getSystem()
 	getter := &Shape[T]{
-		shapeBase:   shapeBase{sys: sys},
-		initializer: initializer,
+		shapeBase:    shapeBase{sys: sys},
+		initializer:  initializer,
+		mainKeyIndex: -1,
 	}
 
 	typ := reflect.TypeOf(getter)
@@ -64,28 +129,75 @@ func NewShape[T any](initializer SystemInitConstraint) *Shape[T] {
 		return nil
 	}
 
+	kinds := map[reflect.Type]shapeFilterKind{}
+	for _, f := range filters {
+		if f.relation != nil {
+			getter.relations = append(getter.relations, relationFilter{
+				store:   f.relation(sys.World().base()),
+				without: f.kind == shapeFilterWithout,
+			})
+			continue
+		}
+		kinds[f.com.Type()] = f.kind
+	}
+
 	getter.cur = new(T)
 	typIns := reflect.TypeOf(*getter.cur)
+	componentType := reflect.TypeOf((*IComponent)(nil)).Elem()
 	for i := 0; i < typIns.NumField(); i++ {
 		field := typIns.Field(i)
-		if !field.Type.Implements(reflect.TypeOf((*IComponent)(nil)).Elem()) || !sys.isRequire(field.Type.Elem()) {
+		if !field.Type.Implements(componentType) {
+			continue
+		}
+		elem := field.Type.Elem()
+		kind := kinds[elem]
+		delete(kinds, elem)
+		if tag := field.Tag.Get("ecs"); tag != "" {
+			var ok bool
+			if kind, ok = shapeFilterTags[tag]; !ok {
+				panic(fmt.Sprintf("invalid shape tag %s on field %s", tag, field.Name))
+			}
+		}
+		fetch := kind == shapeFilterNone || kind == shapeFilterOptional || kind == shapeFilterChanged || kind == shapeFilterAdded
+		if !fetch || !sys.isRequire(elem) {
+			// 未声明的Changed、Added字段仅作为过滤条件
+			if kind != shapeFilterNone && kind != shapeFilterOptional {
+				getter.addFilter(reflect.New(elem).Interface().(IComponent), kind)
+			}
 			continue
 		}
-		if r, ok := sysReq[field.Type.Elem()]; ok {
+		if r, ok := sysReq[elem]; ok {
 			if r.getPermission() == ComponentReadOnly {
 				getter.readOnly = append(getter.readOnly, true)
 			} else {
 				getter.readOnly = append(getter.readOnly, false)
 			}
 		}
-		meta := sys.World().getComponentMetaInfoByType(field.Type.Elem())
+		meta := sys.World().getComponentMetaInfoByType(elem)
 		getter.subTypes = append(getter.subTypes, meta.it)
 		getter.subOffset = append(getter.subOffset, field.Offset)
+		getter.subKinds = append(getter.subKinds, kind)
+	}
+	for _, f := range filters {
+		if f.relation != nil {
+			continue
+		}
+		if kind, ok := kinds[f.com.Type()]; ok && kind == f.kind && kind != shapeFilterOptional {
+			getter.addFilter(f.com, f.kind)
+		}
 	}
 
 	getter.containers = make([]IComponentSet, len(getter.subTypes))
+	getter.filterContainers = make([]IComponentSet, len(getter.filterTypes))
+	getter.siblings = make([]unsafe.Pointer, len(getter.subTypes))
 
-	if len(getter.subTypes) == 0 {
+	required := 0
+	for _, kind := range getter.subKinds {
+		if kind != shapeFilterOptional {
+			required++
+		}
+	}
+	if required == 0 {
 		return nil
 	}
 
@@ -94,6 +206,17 @@ func NewShape[T any](initializer SystemInitConstraint) *Shape[T] {
 	return getter
 }
 
+func (s *Shape[T]) addFilter(com IComponent, kind shapeFilterKind) {
+	meta := s.sys.World().getOrCreateComponentMetaInfo(com)
+	for _, it := range s.filterTypes {
+		if it == meta.it {
+			return
+		}
+	}
+	s.filterTypes = append(s.filterTypes, meta.it)
+	s.filterKinds = append(s.filterKinds, kind)
+}
+
 func (s *Shape[T]) IsValid() bool {
 	return s.valid
 }
@@ -105,64 +228,114 @@ func (s *Shape[T]) getType() reflect.Type {
 	return s.typ
 }
 
+func (s *Shape[T]) indices() ShapeIndices {
+	return ShapeIndices{
+		subTypes:         s.subTypes,
+		subOffset:        s.subOffset,
+		containers:       s.containers,
+		readOnly:         s.readOnly,
+		subKinds:         s.subKinds,
+		filterContainers: s.filterContainers,
+		filterKinds:      s.filterKinds,
+		relations:        s.relations,
+		since:            s.sys.lastRunTick(),
+		tick:             s.sys.World().base().currentTick(),
+	}
+}
+
+// 更新组件容器，必需的组件或过滤条件要求存在的组件没有任何实例时返回false
+func (s *Shape[T]) refresh() bool {
+	for i := 0; i < len(s.subTypes); i++ {
+		c := s.sys.World().getComponentSetByIntType(s.subTypes[i])
+		if s.subKinds[i] != shapeFilterOptional && (c == nil || c.Len() == 0) {
+			return false
+		}
+		s.containers[i] = c
+	}
+	for i := 0; i < len(s.filterTypes); i++ {
+		c := s.sys.World().getComponentSetByIntType(s.filterTypes[i])
+		if s.filterKinds[i] != shapeFilterWithout && (c == nil || c.Len() == 0) {
+			return false
+		}
+		s.filterContainers[i] = c
+	}
+	return true
+}
+
 func (s *Shape[T]) Get() IShapeIterator[T] {
 	s.executeNum++
 
-	if !s.valid {
+	if !s.valid || !s.refresh() {
 		return EmptyShapeIter[T]()
 	}
 
-	var mainComponent IComponentSet
-	var mainKeyIndex int
-	for i := 0; i < len(s.subTypes); i++ {
-		c := s.sys.World().getComponentSetByIntType(s.subTypes[i])
-		if c == nil || c.Len() == 0 {
-			return EmptyShapeIter[T]()
-		}
-		if mainComponent == nil || mainComponent.Len() > c.Len() {
-			mainComponent = c
-			mainKeyIndex = i
+	return NewShapeIterator[T](s.indices(), s.mainKey())
+}
+
+// 未指定主键时选择实例最少的组件作为主键，可选组件不作为主键
+func (s *Shape[T]) mainKey() int {
+	mainKeyIndex := s.mainKeyIndex
+	if mainKeyIndex < 0 {
+		for i := 0; i < len(s.subTypes); i++ {
+			if s.subKinds[i] == shapeFilterOptional {
+				continue
+			}
+			if mainKeyIndex < 0 || s.containers[mainKeyIndex].Len() > s.containers[i].Len() {
+				mainKeyIndex = i
+			}
 		}
-		s.containers[i] = c
 	}
+	return mainKeyIndex
+}
+
+// ParallelForEach 将主键组件的数据按batchSize分块，在协程池中并行遍历满足条件的实体，全部执行完后返回，
+// batchSize<=0时自动分块。fn会被并发调用，传入的shape仅在本次调用中有效
+func (s *Shape[T]) ParallelForEach(batchSize int, fn func(shape *T)) {
+	s.executeNum++
 
-	if s.mainKeyIndex == 0 {
-		mainKeyIndex = s.mainKeyIndex
-		mainComponent = s.containers[mainKeyIndex]
+	if !s.valid || !s.refresh() {
+		return
 	}
 
-	return NewShapeIterator[T](
-		ShapeIndices{
-			subTypes:   s.subTypes,
-			subOffset:  s.subOffset,
-			containers: s.containers,
-			readOnly:   s.readOnly,
-		},
-		mainKeyIndex)
+	mainKeyIndex := s.mainKey()
+	indices := s.indices()
+	main := indices.containers[mainKeyIndex]
+	s.sys.World().base().parallelFor(s.sys.getWorker(), main.Len(), batchSize, func(begin, end int) {
+		cur := new(T)
+		siblings := make([]unsafe.Pointer, len(indices.subTypes))
+		for i := begin; i < end; i++ {
+			p := main.getPointerByIndex(int64(i))
+			siblings[mainKeyIndex] = p
+			if !indices.match((*EmptyComponent)(p).Owner(), mainKeyIndex, siblings) {
+				continue
+			}
+			for j := 0; j < len(siblings); j++ {
+				indices.trans(unsafe.Pointer(cur), j, siblings[j])
+			}
+			fn(cur)
+		}
+	})
 }
 
 func (s *Shape[T]) GetSpecific(entity Entity) (*T, bool) {
-	if !s.valid {
+	if !s.valid || !s.refresh() {
 		return s.cur, false
 	}
-	for i := 0; i < len(s.subTypes); i++ {
-		subPointer := s.containers[i].getPointerByEntity(entity)
-		if subPointer == nil {
-			return s.cur, false
-		}
-		if s.readOnly[i] {
-			*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.subOffset[i])) = &(*(*byte)(subPointer))
-		} else {
-			*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.subOffset[i])) = (*byte)(subPointer)
-		}
+	indices := s.indices()
+	if !indices.match(entity, -1, s.siblings) {
+		return s.cur, false
+	}
+	for i := 0; i < len(s.siblings); i++ {
+		indices.trans(unsafe.Pointer(s.cur), i, s.siblings[i])
 	}
 	return s.cur, true
 }
 
+// SetGuide 指定遍历的主键组件，可选组件不能作为主键
 func (s *Shape[T]) SetGuide(component IComponent) *Shape[T] {
 	meta := s.initializer.getSystem().World().getComponentMetaInfoByType(component.Type())
 	for i, r := range s.subTypes {
-		if r == meta.it {
+		if r == meta.it && s.subKinds[i] != shapeFilterOptional {
 			s.mainKeyIndex = i
 			return s
 		}
@@ -26,13 +26,14 @@ type __ShapeGetter_Test_S_1 struct {
 	getter1 *Shape[__ShapeGetter_Test_Shape_1]
 }
 
-func (t *__ShapeGetter_Test_S_1) Init(initializer SystemInitConstraint) {
+func (t *__ShapeGetter_Test_S_1) Init(initializer SystemInitConstraint) error {
 	t.SetRequirements(initializer, &__ShapeGetter_Test_C_1{}, &__ShapeGetter_Test_C_2{})
 
 	t.getter1 = NewShape[__ShapeGetter_Test_Shape_1](initializer)
 	if t.getter1 == nil {
 		initializer.SetBroken("invalid getter")
 	}
+	return nil
 }
 
 func (t *__ShapeGetter_Test_S_1) Update(event Event) {
@@ -58,3 +59,100 @@ func TestNewShapeGetter(t *testing.T) {
 	time.Sleep(time.Second)
 	world.Update()
 }
+
+type __ShapeGetter_Test_C_3 struct {
+	Component[__ShapeGetter_Test_C_3]
+}
+
+type __ShapeGetter_Test_C_4 struct {
+	Component[__ShapeGetter_Test_C_4]
+}
+
+type __ShapeGetter_Test_Shape_2 struct {
+	c1   *__ShapeGetter_Test_C_1
+	c2   *__ShapeGetter_Test_C_2 `ecs:"optional"`
+	dead *__ShapeGetter_Test_C_3 `ecs:"without"`
+}
+
+type __ShapeGetter_Test_Shape_3 struct {
+	c1 *__ShapeGetter_Test_C_1
+	c2 *__ShapeGetter_Test_C_2
+}
+
+type __ShapeGetter_Test_S_2 struct {
+	System[__ShapeGetter_Test_S_2]
+
+	getter2 *Shape[__ShapeGetter_Test_Shape_2]
+	getter3 *Shape[__ShapeGetter_Test_Shape_3]
+	result2 map[int]int
+	result3 map[int]bool
+}
+
+func (t *__ShapeGetter_Test_S_2) Init(initializer SystemInitConstraint) error {
+	t.SetRequirements(initializer, &__ShapeGetter_Test_C_1{}, &ReadOnly[__ShapeGetter_Test_C_2]{})
+
+	t.getter2 = NewShape[__ShapeGetter_Test_Shape_2](initializer)
+	t.getter3 = NewShape[__ShapeGetter_Test_Shape_3](initializer,
+		With[__ShapeGetter_Test_C_4](), Optional[__ShapeGetter_Test_C_2]())
+	if t.getter2 == nil || t.getter3 == nil {
+		initializer.SetBroken("invalid getter")
+	}
+	return nil
+}
+
+func (t *__ShapeGetter_Test_S_2) Update(event Event) {
+	t.result2 = map[int]int{}
+	iter2 := t.getter2.Get()
+	for s := iter2.Begin(); !iter2.End(); s = iter2.Next() {
+		t.result2[s.c1.Field1] = -1
+		if s.c2 != nil {
+			t.result2[s.c1.Field1] = s.c2.Field1
+		}
+	}
+	t.result3 = map[int]bool{}
+	iter3 := t.getter3.Get()
+	for s := iter3.Begin(); !iter3.End(); s = iter3.Next() {
+		t.result3[s.c1.Field1] = s.c2 != nil
+	}
+}
+
+func TestShape_Filter(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__ShapeGetter_Test_S_2](world)
+	world.Startup()
+
+	// 0: c1 c2, 1: c1, 2: c1 c2 dead, 3: c1 tag, 4: c1 c2 tag, 5: c2
+	components := [][]IComponent{
+		{&__ShapeGetter_Test_C_1{Field1: 0}, &__ShapeGetter_Test_C_2{Field1: 10}},
+		{&__ShapeGetter_Test_C_1{Field1: 1}},
+		{&__ShapeGetter_Test_C_1{Field1: 2}, &__ShapeGetter_Test_C_2{Field1: 20}, &__ShapeGetter_Test_C_3{}},
+		{&__ShapeGetter_Test_C_1{Field1: 3}, &__ShapeGetter_Test_C_4{}},
+		{&__ShapeGetter_Test_C_1{Field1: 4}, &__ShapeGetter_Test_C_2{Field1: 40}, &__ShapeGetter_Test_C_4{}},
+		{&__ShapeGetter_Test_C_2{Field1: 50}},
+	}
+	for _, cs := range components {
+		world.Add(world.NewEntity(), cs...)
+	}
+	world.Update()
+	world.Update()
+
+	sys, _ := world.getSystem(TypeOf[__ShapeGetter_Test_S_2]())
+	s := sys.(*__ShapeGetter_Test_S_2)
+	want2 := map[int]int{0: 10, 1: -1, 3: -1, 4: 40}
+	if len(s.result2) != len(want2) {
+		t.Errorf("optional/without result want %v, got %v", want2, s.result2)
+	}
+	for k, v := range want2 {
+		if s.result2[k] != v {
+			t.Errorf("optional/without result want %v, got %v", want2, s.result2)
+		}
+	}
+	want3 := map[int]bool{3: false, 4: true}
+	if len(s.result3) != len(want3) || s.result3[3] != false || s.result3[4] != true {
+		t.Errorf("with result want %v, got %v", want3, s.result3)
+	}
+	world.Stop()
+}
@@ -16,6 +16,7 @@ type ShapeIter[T any] struct {
 	begin        int
 	mainKeyIndex int
 	cur          *T
+	siblings     []unsafe.Pointer
 }
 
 func EmptyShapeIter[T any]() IShapeIterator[T] {
@@ -28,6 +29,7 @@ func NewShapeIterator[T any](indices ShapeIndices, mainKeyIndex int) IShapeItera
 		maxLen:       indices.containers[mainKeyIndex].Len(),
 		mainKeyIndex: mainKeyIndex,
 		offset:       0,
+		siblings:     make([]unsafe.Pointer, len(indices.subTypes)),
 	}
 
 	return iter
@@ -35,26 +37,21 @@ func NewShapeIterator[T any](indices ShapeIndices, mainKeyIndex int) IShapeItera
 
 // TODO 热点
 func (s *ShapeIter[T]) tryNext() *T {
-	skip := false
 	find := false
 	var p unsafe.Pointer
-	var ec *EmptyComponent
 	for i := s.offset; i < s.maxLen; i++ {
 		//TODO check if this is the best way to do this
-		p = s.indices.containers[s.mainKeyIndex].getPointerByIndex(int64(s.offset))
-		ec = (*EmptyComponent)(p)
-		if s.indices.readOnly[s.mainKeyIndex] {
-			*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[s.mainKeyIndex])) = &(*(*byte)(p))
-		} else {
-			*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[s.mainKeyIndex])) = (*byte)(p)
+		p = s.indices.containers[s.mainKeyIndex].getPointerByIndex(int64(i))
+		s.siblings[s.mainKeyIndex] = p
+		if !s.indices.match((*EmptyComponent)(p).Owner(), s.mainKeyIndex, s.siblings) {
+			continue
 		}
-		entity := ec.Owner()
-		skip = s.getSiblings(entity)
-		if !skip {
-			s.offset = i
-			find = true
-			break
+		for j := 0; j < len(s.siblings); j++ {
+			s.trans(j, s.siblings[j])
 		}
+		s.offset = i
+		find = true
+		break
 	}
 	if !find {
 		s.cur = nil
@@ -63,25 +60,67 @@ func (s *ShapeIter[T]) tryNext() *T {
 	return s.cur
 }
 
-func (s *ShapeIter[T]) getSiblings(entity Entity) bool {
-	for i := 0; i < len(s.indices.subTypes); i++ {
-		if i == s.mainKeyIndex {
-			continue
+// 检查实体是否满足过滤条件，并取出除主键外的组件指针，可选组件不存在时为nil，
+// 主键组件的指针需预先放入siblings
+func (s *ShapeIndices) match(entity Entity, mainKeyIndex int, siblings []unsafe.Pointer) bool {
+	for _, r := range s.relations {
+		if r.store.hasTargets(entity) == r.without {
+			return false
+		}
+	}
+	for i := 0; i < len(s.filterContainers); i++ {
+		var p unsafe.Pointer
+		if c := s.filterContainers[i]; c != nil {
+			p = c.getPointerByEntity(entity)
 		}
-		subPointer := s.indices.containers[i].getPointerByEntity(entity)
-		if subPointer == nil {
-			return true
+		if !s.satisfy(s.filterKinds[i], p) {
+			return false
 		}
-		s.trans(i, subPointer)
 	}
-	return false
+	for i := 0; i < len(s.subTypes); i++ {
+		if i != mainKeyIndex {
+			siblings[i] = nil
+			if s.containers[i] != nil {
+				siblings[i] = s.containers[i].getPointerByEntity(entity)
+			}
+		}
+		if !s.satisfy(s.subKinds[i], siblings[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ShapeIndices) satisfy(kind shapeFilterKind, p unsafe.Pointer) bool {
+	switch kind {
+	case shapeFilterOptional:
+		return true
+	case shapeFilterWithout:
+		return p == nil
+	case shapeFilterChanged:
+		return p != nil && tickBefore(s.since, (*EmptyComponent)(p).changed)
+	case shapeFilterAdded:
+		return p != nil && tickBefore(s.since, (*EmptyComponent)(p).added)
+	default:
+		return p != nil
+	}
 }
 
 func (s *ShapeIter[T]) trans(i int, subPointer unsafe.Pointer) {
-	if s.indices.readOnly[i] {
-		*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[i])) = &(*(*byte)(subPointer))
+	s.indices.trans(unsafe.Pointer(s.cur), i, subPointer)
+}
+
+// 将第i个组件的指针写入shape的对应字段
+func (s *ShapeIndices) trans(cur unsafe.Pointer, i int, subPointer unsafe.Pointer) {
+	if subPointer == nil {
+		*(**byte)(unsafe.Add(cur, s.subOffset[i])) = nil
+		return
+	}
+	if s.readOnly[i] {
+		*(**byte)(unsafe.Add(cur, s.subOffset[i])) = &(*(*byte)(subPointer))
 	} else {
-		*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[i])) = (*byte)(subPointer)
+		*(**byte)(unsafe.Add(cur, s.subOffset[i])) = (*byte)(subPointer)
+		markChanged(subPointer, s.tick)
 	}
 }
 
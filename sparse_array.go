@@ -74,11 +74,16 @@ func (g *SparseArray[K, V]) Add(key K, value *V) *V {
 	return &g.data[idx]
 }
 
+// Remove 将末尾元素移动到被删除的位置，稠密数据的顺序取决于增删的先后，
+// 确定性模式依靠主线程串行执行和getTempTasks按类型排序保证每次的操作顺序相同
 func (g *SparseArray[K, V]) Remove(key K) *V {
-	if key > g.maxKey {
+	if key > g.maxKey || int(key) >= len(g.indices) {
 		return nil
 	}
 	idx := g.indices[key] - 1
+	if idx < 0 {
+		return nil
+	}
 	removed, oldIndex, newIndex := g.UnorderedCollection.Remove(int64(idx))
 
 	lastKey := g.idx2Key[int32(oldIndex)]
@@ -93,14 +98,14 @@ func (g *SparseArray[K, V]) Remove(key K) *V {
 }
 
 func (g *SparseArray[K, V]) Exist(key K) bool {
-	if key > g.maxKey {
+	if key > g.maxKey || int(key) >= len(g.indices) {
 		return false
 	}
 	return !(g.indices[key] == 0)
 }
 
 func (g *SparseArray[K, V]) Get(key K) *V {
-	if key > g.maxKey {
+	if key > g.maxKey || int(key) >= len(g.indices) {
 		return nil
 	}
 	idx := g.indices[key] - 1
@@ -152,3 +157,30 @@ func (g *SparseArray[K, V]) shrink(key K) {
 		}
 	}
 }
+
+// 回滚用的稠密数据和索引副本，idx2Key在恢复时由indices重建
+type sparseArrayState[K Integer, V any] struct {
+	data    []V
+	indices []int32
+	maxKey  K
+}
+
+// 复用s中的切片保存当前数据
+func (g *SparseArray[K, V]) saveState(s *sparseArrayState[K, V]) {
+	s.data = append(s.data[:0], g.data[:g.len]...)
+	s.indices = append(s.indices[:0], g.indices...)
+	s.maxKey = g.maxKey
+}
+
+func (g *SparseArray[K, V]) loadState(s *sparseArrayState[K, V]) {
+	g.data = append(g.data[:0], s.data...)
+	g.len = int64(len(s.data))
+	g.indices = append(g.indices[:0], s.indices...)
+	g.maxKey = s.maxKey
+	g.idx2Key = make(map[int32]int32, len(s.data))
+	for key, idx := range g.indices {
+		if idx != 0 {
+			g.idx2Key[idx-1] = int32(key)
+		}
+	}
+}
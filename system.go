@@ -3,6 +3,7 @@ package ecs
 import (
 	"reflect"
 	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -53,6 +54,7 @@ type ISystem interface {
 	getPointer() unsafe.Pointer
 	isRequire(componentType reflect.Type) bool
 	setOrder(order Order)
+	reorder(order Order)
 	setRequirements(initializer SystemInitConstraint, rqs ...IRequirement)
 	getState() SystemState
 	setState(state SystemState)
@@ -60,12 +62,25 @@ type ISystem interface {
 	isThreadSafe() bool
 	setExecuting(isExecuting bool)
 	isExecuting() bool
+	setWorker(w *Worker)
+	getWorker() *Worker
 	baseInit(world *ecsWorld, ins ISystem)
 	getOptimizer() *OptimizerReporter
 	getGetterCache() *GetterCache
 	setBroken()
 	isValid() bool
 	setUtility(u IUtility)
+	markRun(frame uint64, tick uint32)
+	lastRunTick() uint32
+	bindEvent(typ reflect.Type, access eventAccess)
+	getSchedule() *systemSchedule
+	addOrdering(ordering systemOrdering)
+	getOrdering() []systemOrdering
+	addSet(name string)
+	getSets() []string
+	getEvents() map[reflect.Type]eventAccess
+	saveRunState() systemRunState
+	loadRunState(s systemRunState)
 }
 
 type SystemObject interface {
@@ -85,6 +100,10 @@ type System[T SystemObject] struct {
 	systemIdentification
 	lock              sync.Mutex
 	requirements      map[reflect.Type]IRequirement
+	events            map[reflect.Type]eventAccess
+	schedule          systemSchedule
+	ordering          []systemOrdering
+	sets              []string
 	getterCache       *GetterCache
 	order             Order
 	optimizerReporter *OptimizerReporter
@@ -95,7 +114,11 @@ type System[T SystemObject] struct {
 	valid             bool
 	isSafe            bool
 	executing         bool
+	worker            *Worker
 	id                int64
+	runFrame          uint64
+	runTick           uint32
+	lastTick          uint32
 }
 
 func (s *System[T]) instance() (sys ISystem) {
@@ -121,6 +144,16 @@ func (s *System[T]) SetRequirements(initializer SystemInitConstraint, rqs ...IRe
 	s.setRequirements(initializer, rqs...)
 }
 
+// SetOptions 在Init中指定系统选项
+func (s *System[T]) SetOptions(initializer SystemInitConstraint, options ...SystemOption) {
+	if initializer.isValid() {
+		panic("out of initialization stage")
+	}
+	for _, option := range options {
+		option.apply(initializer.getSystem())
+	}
+}
+
 func (s *System[T]) setRequirementsInternal(rqs ...IRequirement) {
 	if s.requirements == nil {
 		s.requirements = map[reflect.Type]IRequirement{}
@@ -206,6 +239,97 @@ func (s *System[T]) isExecuting() bool {
 	return s.executing
 }
 
+// 系统在协程池中执行时所在的worker，在主线程中执行时为nil
+func (s *System[T]) setWorker(w *Worker) {
+	s.worker = w
+}
+
+func (s *System[T]) getWorker() *Worker {
+	return s.worker
+}
+
+// 记录系统本次执行的tick，同一帧内多个阶段的执行视为同一次
+func (s *System[T]) markRun(frame uint64, tick uint32) {
+	if s.runFrame != frame {
+		s.lastTick = s.runTick
+		s.runFrame = frame
+	}
+	s.runTick = tick
+}
+
+// 系统上一帧最后一次执行时的tick，此后的修改对系统而言是新的修改
+func (s *System[T]) lastRunTick() uint32 {
+	return s.lastTick
+}
+
+func (s *System[T]) bindEvent(typ reflect.Type, access eventAccess) {
+	if s.events == nil {
+		s.events = map[reflect.Type]eventAccess{}
+	}
+	s.events[typ] |= access
+}
+
+func (s *System[T]) getEvents() map[reflect.Type]eventAccess {
+	return s.events
+}
+
+func (s *System[T]) getSchedule() *systemSchedule {
+	return &s.schedule
+}
+
+func (s *System[T]) addOrdering(ordering systemOrdering) {
+	s.ordering = append(s.ordering, ordering)
+}
+
+func (s *System[T]) getOrdering() []systemOrdering {
+	return s.ordering
+}
+
+func (s *System[T]) addSet(name string) {
+	for _, set := range s.sets {
+		if set == name {
+			return
+		}
+	}
+	s.sets = append(s.sets, name)
+}
+
+func (s *System[T]) getSets() []string {
+	return s.sets
+}
+
+// 回滚时需要恢复的系统运行状态
+type systemRunState struct {
+	state    SystemState
+	runFrame uint64
+	runTick  uint32
+	lastTick uint32
+	elapsed  time.Duration
+	started  bool
+	order    Order
+}
+
+func (s *System[T]) saveRunState() systemRunState {
+	return systemRunState{
+		state:    s.state,
+		runFrame: s.runFrame,
+		runTick:  s.runTick,
+		lastTick: s.lastTick,
+		elapsed:  s.schedule.elapsed,
+		started:  s.schedule.started,
+		order:    s.order,
+	}
+}
+
+func (s *System[T]) loadRunState(rs systemRunState) {
+	s.state = rs.state
+	s.runFrame = rs.runFrame
+	s.runTick = rs.runTick
+	s.lastTick = rs.lastTick
+	s.schedule.elapsed = rs.elapsed
+	s.schedule.started = rs.started
+}
+
 func (s *System[T]) GetRequirements() map[reflect.Type]IRequirement {
 	return s.requirements
 }
@@ -258,14 +382,20 @@ func (s *System[T]) Type() reflect.Type {
 	return s.realType
 }
 
+// 注册时集合指定的顺序在Init之后应用，系统开始更新后不能再修改顺序
 func (s *System[T]) setOrder(order Order) {
-	if s.isInitialized() {
+	if s.state > SystemStateStart {
 		return
 	}
 
 	s.order = order
 }
 
+// 集合调整顺序时在同步点调用，不受系统状态限制
+func (s *System[T]) reorder(order Order) {
+	s.order = order
+}
+
 func (s *System[T]) Order() Order {
 	return s.order
 }
@@ -278,6 +408,11 @@ func (s *System[T]) GetEntityInfo(entity Entity) (*EntityInfo, bool) {
 	return s.world.getEntityInfo(entity)
 }
 
+// IsAlive 实体存在且未被销毁，组件中保存的Entity使用前可以先检查
+func (s *System[T]) IsAlive(entity Entity) bool {
+	return s.world.base().IsAlive(entity)
+}
+
 // get optimizer
 func (s *System[T]) getOptimizer() *OptimizerReporter {
 	if s.optimizerReporter == nil {
@@ -31,6 +31,32 @@ const (
 // Stage system execute period:start->pre_update->update->pre_destroy->destroy
 type Stage uint32
 
+var stageNames = map[Stage]string{
+	StageSyncBeforeStart: "StageSyncBeforeStart",
+	StageStart:           "StageStart",
+	StageSyncAfterStart:  "StageSyncAfterStart",
+
+	StageSyncBeforePreUpdate: "StageSyncBeforePreUpdate",
+	StagePreUpdate:           "StagePreUpdate",
+	StageSyncAfterPreUpdate:  "StageSyncAfterPreUpdate",
+
+	StageSyncBeforeUpdate: "StageSyncBeforeUpdate",
+	StageUpdate:           "StageUpdate",
+	StageSyncAfterUpdate:  "StageSyncAfterUpdate",
+
+	StageSyncBeforePostUpdate: "StageSyncBeforePostUpdate",
+	StagePostUpdate:           "StagePostUpdate",
+	StageSyncAfterPostUpdate:  "StageSyncAfterPostUpdate",
+
+	StageSyncBeforeDestroy: "StageSyncBeforeDestroy",
+	StageDestroy:           "StageDestroy",
+	StageSyncAfterDestroy:  "StageSyncAfterDestroy",
+}
+
+func (s Stage) String() string {
+	return stageNames[s]
+}
+
 // Order default suborder of system
 type Order int32
 
@@ -46,17 +72,44 @@ type SystemGroupList []*SystemGroup
 
 // system execute flow
 type systemFlow struct {
-	world     *ecsWorld
-	stages    map[Stage]SystemGroupList
-	stageList []Stage
-	systems   map[reflect.Type]ISystem
-	wg        *sync.WaitGroup
+	world      *ecsWorld
+	stages     map[Stage]SystemGroupList
+	stageList  []Stage
+	systems    map[reflect.Type]ISystem
+	sets       map[string]*systemSet
+	wg         *sync.WaitGroup
+	lock       sync.Mutex
+	adding     []ISystem
+	removing   []reflect.Type
+	destroying []ISystem
+	controls   []systemControl
+	panics     []panicRecord
+}
+
+type systemControlOp uint8
+
+const (
+	systemControlPause systemControlOp = iota
+	systemControlResume
+	systemControlStop
+	systemControlPauseSet
+	systemControlResumeSet
+	systemControlReorderSet
+)
+
+// 系统或集合的控制操作，集合的操作使用set、order
+type systemControl struct {
+	typ   reflect.Type
+	set   string
+	order Order
+	op    systemControlOp
 }
 
 func newSystemFlow(runtime *ecsWorld) *systemFlow {
 	sf := &systemFlow{
 		world:   runtime,
 		systems: map[reflect.Type]ISystem{},
+		sets:    map[string]*systemSet{},
 		wg:      &sync.WaitGroup{},
 	}
 	sf.init()
@@ -102,17 +155,31 @@ func (p *systemFlow) reset() {
 }
 
 func (p *systemFlow) flushTempTask() {
+	p.world.applyHierarchy()
+	p.world.applyRelations()
 	tasks := p.world.components.getTempTasks()
+	if p.world.config.Deterministic {
+		for _, task := range tasks {
+			task()
+		}
+		p.world.components.callHooks()
+		p.world.freeEntities()
+		return
+	}
 	p.wg.Add(len(tasks))
+	jobs := make([]func(), 0, len(tasks))
 	for _, task := range tasks {
 		wg := p.wg
 		fn := task
-		p.world.addJob(func() {
+		jobs = append(jobs, func() {
+			defer wg.Done()
 			fn()
-			wg.Done()
 		})
 	}
+	p.world.addJobs(jobs)
 	p.wg.Wait()
+	p.world.components.callHooks()
+	p.world.freeEntities()
 }
 
 func (p *systemFlow) systemUpdate(event Event) {
@@ -121,6 +188,7 @@ func (p *systemFlow) systemUpdate(event Event) {
 	var imp bool = false
 	var runSync bool = false
 	var fn func(event Event)
+	var jobs []func(w *Worker)
 	for _, period := range p.stageList {
 		sq = p.stages[period]
 		for _, sl := range sq {
@@ -128,6 +196,7 @@ func (p *systemFlow) systemUpdate(event Event) {
 				continue
 			}
 			for ss := sl.Begin(); !sl.End(); ss = sl.Next() {
+				tick := p.world.advanceTick()
 				if systemCount := len(ss); systemCount != 0 {
 					for i := 0; i < systemCount; i++ {
 						sys = ss[i]
@@ -139,6 +208,9 @@ func (p *systemFlow) systemUpdate(event Event) {
 						imp = false
 						runSync = false
 						state := ss[i].getState()
+						if state == SystemStatePause || state == SystemStateDestroyed {
+							continue
+						}
 
 						if period > StageSyncAfterStart {
 							if state == SystemStateStart {
@@ -249,29 +321,44 @@ func (p *systemFlow) systemUpdate(event Event) {
 						if !imp {
 							continue
 						}
-						if runSync {
+						// 更新阶段在派发前求值执行条件，按系统的执行节奏跳过或重复执行
+						runs, e := 1, event
+						if state == SystemStateUpdate {
+							runs, e.Delta = sys.getSchedule().dispatch(sys, event)
+							if runs == 0 {
+								continue
+							}
+						}
+						sys.markRun(event.Frame, tick)
+						if runSync || p.world.config.Deterministic {
 							sys.setExecuting(true)
 							sys.setSecurity(true)
-							fn(event)
+							p.execute(sys, period, fn, e, runs)
 							sys.setSecurity(false)
 							sys.setExecuting(false)
 						} else {
-							wrapper := func(fn func(event2 Event), e Event) func() {
+							wrapper := func(sys ISystem, period Stage, fn func(event2 Event), e Event, runs int) func(w *Worker) {
 								sys.setExecuting(true)
-								return func() {
+								return func(w *Worker) {
+									sys.setWorker(w)
 									defer func() {
+										sys.setWorker(nil)
 										sys.setExecuting(false)
 										p.wg.Done()
 									}()
-									fn(e)
+									p.execute(sys, period, fn, e, runs)
 								}
 							}
 							p.wg.Add(1)
-							p.world.addJob(wrapper(fn, event))
+							jobs = append(jobs, wrapper(sys, period, fn, e, runs))
 						}
 					}
 				}
+				// 同一批次的并行系统一次投递
+				p.world.addWorkerJobs(jobs)
+				jobs = jobs[:0]
 				p.wg.Wait()
+				p.reportPanics()
 			}
 		}
 	}
@@ -281,10 +368,18 @@ func (p *systemFlow) run(event Event) {
 	reporter := p.world.metrics.NewReporter("system_flow_run")
 	reporter.Start()
 
+	p.applyPending()
+
 	//Log.Info("system flow # Temp Task Execute #")
 	p.flushTempTask()
 	reporter.Sample("Temp Task Execute")
 
+	p.world.swapEvents()
+
+	for _, sys := range p.systems {
+		sys.getSchedule().prepare(event)
+	}
+
 	//Log.Info("system flow # Logic #")
 	p.systemUpdate(event)
 	reporter.Sample("system execute")
@@ -293,9 +388,12 @@ func (p *systemFlow) run(event Event) {
 	p.world.components.clearDisposable()
 	reporter.Sample("Clear Disposable")
 
+	p.world.advanceTick()
 	p.flushTempTask()
 	reporter.Sample("Temp Task Execute")
 
+	p.clearDestroyed()
+
 	reporter.Stop()
 	reporter.Print()
 }
@@ -305,16 +403,36 @@ func (p *systemFlow) register(system ISystem) {
 	if p.world.getStatus() != WorldStatusInitialized {
 		panic("system register only in world init")
 	}
+	p.insert(system)
+}
+
+// 初始化系统并加入其实现的各阶段的系统组
+func (p *systemFlow) insert(system ISystem) bool {
+	if _, ok := p.systems[system.Type()]; ok {
+		Log.Errorf("repeated system %s", system.Type().Name())
+		return false
+	}
 
 	//init function call
 	system.baseInit(p.world, system)
 
 	order := system.Order()
+	if setOrder := p.joinSets(system); setOrder != OrderInvalid {
+		order = setOrder
+		system.setOrder(order)
+	}
 	if order > OrderAppend {
 		Log.Errorf("system order must less then %d, resort order to %d", OrderAppend+1, OrderAppend)
 		order = OrderAppend
 	}
+	p.addToStages(system, order)
+
+	p.systems[system.Type()] = system
+	return true
+}
 
+// 按order将系统加入其实现的各阶段的系统组
+func (p *systemFlow) addToStages(system ISystem, order Order) {
 	for _, period := range p.stageList {
 
 		if !p.isImpEvent(system, period) {
@@ -335,15 +453,167 @@ func (p *systemFlow) register(system ISystem) {
 					sg := NewSystemGroup()
 					sg.order = order
 					sg.insert(system)
-					temp := append(SystemGroupList{}, sl[i-1:]...)
-					p.stages[period] = append(append(sl[:i-1], sg), temp...)
+					temp := append(SystemGroupList{}, sl[i:]...)
+					p.stages[period] = append(append(sl[:i], sg), temp...)
 					break
 				}
 			}
 		}
 	}
+}
 
-	p.systems[system.Type()] = system
+func (p *systemFlow) removeFromStages(system ISystem) {
+	for _, period := range p.stageList {
+		for _, sg := range p.stages[period] {
+			if sg.has(system) {
+				sg.remove(system)
+			}
+		}
+	}
+}
+
+// 从各阶段的系统组、集合中移除系统
+func (p *systemFlow) unregister(system ISystem) {
+	p.removeFromStages(system)
+	for _, set := range system.getSchedule().sets {
+		for i, sys := range set.systems {
+			if sys == system {
+				set.systems = append(set.systems[:i], set.systems[i+1:]...)
+				break
+			}
+		}
+	}
+	if u := system.GetUtility(); u != nil {
+		delete(p.world.utilities, u.Type())
+	}
+	delete(p.systems, system.Type())
+}
+
+// 运行中添加系统，在下一帧开始的同步点生效
+func (p *systemFlow) addSystem(system ISystem) {
+	if p.world.getStatus() == WorldStatusInitialized {
+		p.register(system)
+		return
+	}
+	p.lock.Lock()
+	p.adding = append(p.adding, system)
+	p.lock.Unlock()
+}
+
+// 运行中移除系统，在下一帧开始的同步点进入销毁阶段，当帧结束后移除
+func (p *systemFlow) removeSystem(typ reflect.Type) {
+	if p.world.getStatus() == WorldStatusInitialized {
+		if sys, ok := p.systems[typ]; ok {
+			p.unregister(sys)
+		}
+		return
+	}
+	p.lock.Lock()
+	p.removing = append(p.removing, typ)
+	p.lock.Unlock()
+}
+
+// 暂停、恢复、停止系统，在下一帧开始的同步点生效
+func (p *systemFlow) controlSystem(typ reflect.Type, op systemControlOp) {
+	p.lock.Lock()
+	p.controls = append(p.controls, systemControl{typ: typ, op: op})
+	p.lock.Unlock()
+}
+
+// 丢弃尚未生效的系统添加、移除以及系统和集合的控制操作，用于回滚
+func (p *systemFlow) discardPending() {
+	p.lock.Lock()
+	p.adding, p.removing, p.controls = nil, nil, nil
+	p.lock.Unlock()
+}
+
+// 同步点，应用运行中添加、移除的系统及系统状态的变更
+func (p *systemFlow) applyPending() {
+	p.lock.Lock()
+	adding, removing, controls := p.adding, p.removing, p.controls
+	p.adding, p.removing, p.controls = nil, nil, nil
+	p.lock.Unlock()
+
+	for _, sys := range adding {
+		if !p.insert(sys) {
+			continue
+		}
+		if err := p.checkOrdering(); err != nil {
+			Log.Error(err)
+			p.unregister(sys)
+		}
+	}
+	for _, typ := range removing {
+		sys, ok := p.systems[typ]
+		if !ok || sys.getState() >= SystemStateDestroy {
+			continue
+		}
+		sys.stop()
+		p.destroying = append(p.destroying, sys)
+	}
+	for _, control := range controls {
+		if control.op >= systemControlPauseSet {
+			p.applySetControl(control)
+			continue
+		}
+		sys, ok := p.systems[control.typ]
+		if !ok {
+			continue
+		}
+		switch control.op {
+		case systemControlPause:
+			sys.pause()
+		case systemControlResume:
+			sys.resume()
+		case systemControlStop:
+			sys.stop()
+		}
+	}
+}
+
+// 本帧已执行过销毁阶段的系统进入Destroyed状态，移除待移除的系统
+func (p *systemFlow) clearDestroyed() {
+	for _, sys := range p.systems {
+		if sys.getState() == SystemStateDestroy {
+			sys.setState(SystemStateDestroyed)
+		}
+	}
+	for _, sys := range p.destroying {
+		p.unregister(sys)
+		sys.setState(SystemStateDestroyed)
+	}
+	p.destroying = nil
+}
+
+// 检查系统间的顺序约束，组内不能有环，跨Order组的约束不能与Order矛盾
+func (p *systemFlow) checkOrdering() error {
+	for _, period := range p.stageList {
+		groups := p.stages[period]
+		for i, sg := range groups {
+			if _, err := sg.topoSort(sg.dependencies()); err != nil {
+				return err
+			}
+			for _, node := range sg.systems {
+				for _, ordering := range node.val.getOrdering() {
+					for j, other := range groups {
+						if ordering.after && j <= i || !ordering.after && j >= i {
+							continue
+						}
+						for _, target := range other.systems {
+							if !ordering.match(target.val) {
+								continue
+							}
+							if ordering.after {
+								return fmt.Errorf("system %s is ordered after %s, but its Order is smaller", node.val.Type().Name(), ordering)
+							}
+							return fmt.Errorf("system %s is ordered before %s, but its Order is larger", node.val.Type().Name(), ordering)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
 }
 
 func (p *systemFlow) isImpEvent(system ISystem, period Stage) bool {
@@ -388,27 +658,7 @@ func (p *systemFlow) stop() {
 }
 
 func (p *systemFlow) SystemInfoPrint() {
-	m := map[Stage]string{
-		StageSyncBeforeStart: "StageSyncBeforeStart",
-		StageStart:           "StageStart",
-		StageSyncAfterStart:  "StageSyncAfterStart",
-
-		StageSyncBeforePreUpdate: "StageSyncBeforePreUpdate",
-		StagePreUpdate:           "StagePreUpdate",
-		StageSyncAfterPreUpdate:  "StageSyncAfterPreUpdate",
-
-		StageSyncBeforeUpdate: "StageSyncBeforeUpdate",
-		StageUpdate:           "StageUpdate",
-		StageSyncAfterUpdate:  "StageSyncAfterUpdate",
-
-		StageSyncBeforePostUpdate: "StageSyncBeforePostUpdate",
-		StagePostUpdate:           "StagePostUpdate",
-		StageSyncAfterPostUpdate:  "StageSyncAfterPostUpdate",
-
-		StageSyncBeforeDestroy: "StageSyncBeforeDestroy",
-		StageDestroy:           "StageDestroy",
-		StageSyncAfterDestroy:  "StageSyncAfterDestroy",
-	}
+	m := stageNames
 	Log.Infof("┌──────────────── # System Info # ─────────────────")
 	Log.Infof("├─ Total: %d", len(p.systems))
 
@@ -0,0 +1,270 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+var __systemFlow_Test_Log []string
+
+type __systemFlow_Test_S_1 struct {
+	System[__systemFlow_Test_S_1]
+}
+
+func (s *__systemFlow_Test_S_1) Init(si SystemInitConstraint) error {
+	__systemFlow_Test_Log = append(__systemFlow_Test_Log, "init")
+	return nil
+}
+
+func (s *__systemFlow_Test_S_1) Start(event Event) {
+	__systemFlow_Test_Log = append(__systemFlow_Test_Log, "start")
+}
+
+func (s *__systemFlow_Test_S_1) Update(event Event) {
+	__systemFlow_Test_Log = append(__systemFlow_Test_Log, "update")
+}
+
+func (s *__systemFlow_Test_S_1) Destroy(event Event) {
+	__systemFlow_Test_Log = append(__systemFlow_Test_Log, "destroy")
+}
+
+func TestSystemFlow_AddRemoveSystem(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	world.Startup()
+	world.Update()
+
+	__systemFlow_Test_Log = nil
+	AddSystem[__systemFlow_Test_S_1](world)
+	if _, ok := world.getSystem(TypeOf[__systemFlow_Test_S_1]()); ok || len(__systemFlow_Test_Log) != 0 {
+		t.Fatal("system should be added at the next sync point")
+	}
+	world.Update()
+	world.Update()
+
+	RemoveSystem[__systemFlow_Test_S_1](world)
+	world.Update()
+	world.Update()
+
+	want := []string{"init", "start", "update", "update", "destroy"}
+	if !reflect.DeepEqual(__systemFlow_Test_Log, want) {
+		t.Errorf("callbacks want %v, got %v", want, __systemFlow_Test_Log)
+	}
+	if _, ok := world.getSystem(TypeOf[__systemFlow_Test_S_1]()); ok {
+		t.Error("removed system still registered")
+	}
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		if sg.systemCount() != 0 {
+			t.Errorf("removed system still scheduled in order %d", sg.order)
+		}
+	}
+	world.Stop()
+}
+
+func TestSystemFlow_OrderGroup(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemFlow_Test_S_1](world, Order(5))
+
+	var orders []Order
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		orders = append(orders, sg.order)
+	}
+	want := []Order{OrderFront, 5, OrderAppend}
+	if !reflect.DeepEqual(orders, want) {
+		t.Errorf("group orders want %v, got %v", want, orders)
+	}
+}
+
+func TestSystemFlow_ControlSystem(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemFlow_Test_S_1](world)
+	world.Startup()
+	world.Update()
+
+	__systemFlow_Test_Log = nil
+	PauseSystem[__systemFlow_Test_S_1](world)
+	world.Update()
+	world.Update()
+	if state, ok := GetSystemState[__systemFlow_Test_S_1](world); !ok || state != SystemStatePause {
+		t.Errorf("state want pause, got %d", state)
+	}
+	ResumeSystem[__systemFlow_Test_S_1](world)
+	world.Update()
+	StopSystem[__systemFlow_Test_S_1](world)
+	world.Update()
+	world.Update()
+	if state, ok := GetSystemState[__systemFlow_Test_S_1](world); !ok || state != SystemStateDestroyed {
+		t.Errorf("state want destroyed, got %d", state)
+	}
+
+	want := []string{"update", "destroy"}
+	if !reflect.DeepEqual(__systemFlow_Test_Log, want) {
+		t.Errorf("callbacks want %v, got %v", want, __systemFlow_Test_Log)
+	}
+	if _, ok := GetSystemState[__systemFlow_Test_S_2](world); ok {
+		t.Error("unregistered system should not have state")
+	}
+	world.Stop()
+}
+
+type __systemFlow_Test_S_2 struct {
+	System[__systemFlow_Test_S_2]
+}
+
+type __systemFlow_Test_S_Panic struct {
+	System[__systemFlow_Test_S_Panic]
+	count int
+}
+
+func (s *__systemFlow_Test_S_Panic) Update(event Event) {
+	s.count++
+	panic("update failed")
+}
+
+type __systemFlow_Test_S_Normal struct {
+	System[__systemFlow_Test_S_Normal]
+	count int
+}
+
+func (s *__systemFlow_Test_S_Normal) Update(event Event) {
+	s.count++
+}
+
+func TestSystemFlow_PanicPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SystemPanicPolicy
+		limit  int
+		runs   int
+		broken bool
+	}{
+		{"break", SystemPanicBreak, 0, 1, true},
+		{"retry", SystemPanicRetry, 0, 4, false},
+		{"retry limit", SystemPanicRetry, 2, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewDefaultWorldConfig()
+			config.Debug = false
+			config.MetaInfoDebugPrint = false
+			config.PanicRetryLimit = tt.limit
+			var infos []SystemPanicInfo
+			config.PanicCallback = func(info SystemPanicInfo) {
+				infos = append(infos, info)
+			}
+			world := NewSyncWorld(config)
+			RegisterSystem[__systemFlow_Test_S_Panic](world, OnPanic(tt.policy))
+			RegisterSystem[__systemFlow_Test_S_Normal](world)
+			world.Startup()
+			for i := 0; i < 4; i++ {
+				world.Update()
+			}
+
+			ps, _ := world.getSystem(TypeOf[__systemFlow_Test_S_Panic]())
+			ns, _ := world.getSystem(TypeOf[__systemFlow_Test_S_Normal]())
+			if got := ps.(*__systemFlow_Test_S_Panic).count; got != tt.runs {
+				t.Errorf("panic system runs want %d, got %d", tt.runs, got)
+			}
+			if got := ns.(*__systemFlow_Test_S_Normal).count; got != 4 {
+				t.Errorf("normal system runs want 4, got %d", got)
+			}
+			if len(infos) != tt.runs {
+				t.Fatalf("callback times want %d, got %d", tt.runs, len(infos))
+			}
+			last := infos[len(infos)-1]
+			if last.System != ps.Type() || last.Stage != StageUpdate || last.Broken != tt.broken || last.Err.Error() != "update failed" {
+				t.Errorf("unexpected panic info %+v", last)
+			}
+			if ps.isValid() == tt.broken {
+				t.Errorf("system broken want %v", tt.broken)
+			}
+			world.Stop()
+		})
+	}
+}
+
+type __systemFlow_Test_C_1 struct {
+	Component[__systemFlow_Test_C_1]
+	Value int
+}
+
+type __systemFlow_Test_S_ParallelPanic struct {
+	System[__systemFlow_Test_S_ParallelPanic]
+}
+
+func (s *__systemFlow_Test_S_ParallelPanic) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__systemFlow_Test_C_1{})
+	return nil
+}
+
+func (s *__systemFlow_Test_S_ParallelPanic) Update(event Event) {
+	ParallelForEach[__systemFlow_Test_C_1](s, 8, func(c *__systemFlow_Test_C_1) {
+		if c.Value == 50 {
+			panic("chunk failed")
+		}
+	})
+}
+
+// 协程池中执行的分块panic时，Update不会阻塞，panic交由系统的策略处理
+func TestSystemFlow_ParallelPanic(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	var infos []SystemPanicInfo
+	config.PanicCallback = func(info SystemPanicInfo) {
+		infos = append(infos, info)
+	}
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemFlow_Test_S_ParallelPanic](world)
+	world.Startup()
+	defer world.Stop()
+
+	for i := 0; i < 100; i++ {
+		world.Add(world.NewEntity(), &__systemFlow_Test_C_1{Value: i})
+	}
+	world.Update()
+	world.Update()
+
+	if len(infos) != 1 {
+		t.Fatalf("callback times want 1, got %d", len(infos))
+	}
+	if infos[0].Err.Error() != "chunk failed" || !infos[0].Broken {
+		t.Errorf("unexpected panic info %+v", infos[0])
+	}
+	if s, _ := world.getSystem(TypeOf[__systemFlow_Test_S_ParallelPanic]()); s.isValid() {
+		t.Error("system should be broken")
+	}
+}
+
+func TestSystemFlow_PanicCrash(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.PanicPolicy = SystemPanicCrash
+	called := false
+	config.PanicCallback = func(info SystemPanicInfo) {
+		called = true
+	}
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemFlow_Test_S_Panic](world)
+	world.Startup()
+	defer world.Stop()
+
+	defer func() {
+		if r := recover(); r != "update failed" {
+			t.Errorf("want panic rethrown, got %v", r)
+		}
+		if !called {
+			t.Error("callback not called before crash")
+		}
+	}()
+	world.Update()
+}
@@ -1,8 +1,10 @@
 package ecs
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 )
 
 var emptySystemGroupIterator = &SystemGroupIterator{}
@@ -25,25 +27,12 @@ func (p *Node) isFriend(node *Node) bool {
 			}
 		}
 	}
-	return false
-}
-
-func (p *Node) attach(node *Node) {
-	isAttached := false
-	for i := 0; i < len(p.children); i++ {
-		if p.children[i].isFriend(node) {
-			p.children[i].attach(node)
-			isAttached = true
-			break
-		}
-	}
-	if !isAttached {
-		if p.val == node.val {
-			Log.Error("repeated system")
-			return
+	for typ, access := range p.val.getEvents() {
+		if access&eventAccessWrite > 0 && node.val.getEvents()[typ]&eventAccessWrite > 0 {
+			return true
 		}
-		p.children = append(p.children, node)
 	}
+	return false
 }
 
 // SystemGroup system group ordered by interrelation
@@ -59,7 +48,7 @@ type SystemGroup struct {
 }
 
 func NewSystemGroup() *SystemGroup {
-	return &SystemGroup{
+	sg := &SystemGroup{
 		systems: make([]*Node, 0),
 		ref:     map[reflect.Type]int{},
 		ordered: true,
@@ -69,6 +58,8 @@ func NewSystemGroup() *SystemGroup {
 			val:      nil,
 		},
 	}
+	sg.group = sg
+	return sg
 }
 
 func (p *SystemGroup) refCount(rqs map[reflect.Type]IRequirement) int {
@@ -83,37 +74,116 @@ func (p *SystemGroup) resort() {
 	if p.ordered {
 		return
 	}
-	sort.Slice(p.systems, func(i, j int) bool {
+	sort.SliceStable(p.systems, func(i, j int) bool {
 		return p.refCount(p.systems[i].val.GetRequirements()) >
 			p.refCount(p.systems[j].val.GetRequirements())
 	})
 
+	deps := p.dependencies()
+	sorted, err := p.topoSort(deps)
+	if err != nil {
+		Log.Error(err)
+		sorted = p.systems
+	}
+
+	// 按依赖与组件冲突分层，每层为一个批次，同层的系统没有冲突和依赖
 	p.root.children = []*Node{}
-	for _, node := range p.systems {
+	level := make(map[*Node]int, len(sorted))
+	count := map[int]int{}
+	p.batchTotal = 0
+	p.maxPeerBatch = 0
+	for i, node := range sorted {
 		node.children = []*Node{}
-		p.root.attach(node)
+		l := 0
+		var parent *Node
+		for _, pre := range sorted[:i] {
+			if deps[node][pre] || pre.isFriend(node) {
+				if level[pre]+1 > l {
+					l = level[pre] + 1
+					parent = pre
+				}
+			}
+		}
+		level[node] = l
+		if parent == nil {
+			p.root.children = append(p.root.children, node)
+		} else {
+			parent.children = append(parent.children, node)
+		}
+		count[l]++
+		if count[l] > p.maxPeerBatch {
+			p.maxPeerBatch = count[l]
+		}
+		if l+1 > p.batchTotal {
+			p.batchTotal = l + 1
+		}
 	}
 	p.ordered = true
 
-	p.batchTotal = 0
-	p.maxPeerBatch = 0
+	p.resetIter()
+}
 
-	var top []*Node = p.root.children
-	for len(top) > 0 {
-		count := 0
-		temp := top
-		top = make([]*Node, 0)
-		for _, node := range temp {
-			count++
-			top = append(top, node.children...)
+// 组内系统的前置依赖，依赖的系统不在组内时忽略
+func (p *SystemGroup) dependencies() map[*Node]map[*Node]bool {
+	deps := make(map[*Node]map[*Node]bool, len(p.systems))
+	link := func(pre, node *Node) {
+		if deps[node] == nil {
+			deps[node] = map[*Node]bool{}
 		}
-		if count > p.maxPeerBatch {
-			p.maxPeerBatch = count
+		deps[node][pre] = true
+	}
+	for _, node := range p.systems {
+		for _, ordering := range node.val.getOrdering() {
+			for _, target := range p.systems {
+				if target == node || !ordering.match(target.val) {
+					continue
+				}
+				if ordering.after {
+					link(target, node)
+				} else {
+					link(node, target)
+				}
+			}
 		}
-		p.batchTotal++
 	}
+	return deps
+}
 
-	p.resetIter()
+// 拓扑排序，依赖之外保持原有顺序
+func (p *SystemGroup) topoSort(deps map[*Node]map[*Node]bool) ([]*Node, error) {
+	sorted := make([]*Node, 0, len(p.systems))
+	done := make(map[*Node]bool, len(p.systems))
+	for len(sorted) < len(p.systems) {
+		var next *Node
+		for _, node := range p.systems {
+			if done[node] {
+				continue
+			}
+			ready := true
+			for pre := range deps[node] {
+				if !done[pre] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = node
+				break
+			}
+		}
+		if next == nil {
+			var names []string
+			for _, node := range p.systems {
+				if !done[node] {
+					names = append(names, node.val.Type().Name())
+				}
+			}
+			return nil, fmt.Errorf("system ordering cycle detected, unresolved systems: %s", strings.Join(names, ", "))
+		}
+		done[next] = true
+		sorted = append(sorted, next)
+	}
+	return sorted, nil
 }
 
 func (p *SystemGroup) resetIter() {
@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -145,3 +146,90 @@ func BenchmarkSystemGroupIter(b *testing.B) {
 		}
 	}
 }
+
+type __systemGroup_Test_S_A struct {
+	System[__systemGroup_Test_S_A]
+}
+
+func (s *__systemGroup_Test_S_A) Update(event Event) {}
+
+type __systemGroup_Test_S_B struct {
+	System[__systemGroup_Test_S_B]
+}
+
+func (s *__systemGroup_Test_S_B) Update(event Event) {}
+
+type __systemGroup_Test_S_C struct {
+	System[__systemGroup_Test_S_C]
+}
+
+func (s *__systemGroup_Test_S_C) Init(si SystemInitConstraint) error {
+	s.SetOptions(si, After[__systemGroup_Test_S_A]())
+	return nil
+}
+
+func (s *__systemGroup_Test_S_C) Update(event Event) {}
+
+func TestSystemGroup_Ordering(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemGroup_Test_S_B](world, After[__systemGroup_Test_S_C]())
+	RegisterSystem[__systemGroup_Test_S_C](world)
+	RegisterSystem[__systemGroup_Test_S_A](world)
+	world.Startup()
+
+	var batches [][]string
+	sg := world.systemFlow.stages[StageUpdate][1]
+	for ss := sg.Begin(); !sg.End(); ss = sg.Next() {
+		var names []string
+		for _, s := range ss {
+			names = append(names, s.Type().Name())
+		}
+		batches = append(batches, names)
+	}
+	want := [][]string{{"__systemGroup_Test_S_A"}, {"__systemGroup_Test_S_C"}, {"__systemGroup_Test_S_B"}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches want %v, got %v", want, batches)
+	}
+	world.Stop()
+}
+
+func TestSystemGroup_OrderingCycle(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemGroup_Test_S_A](world, After[__systemGroup_Test_S_C]())
+	RegisterSystem[__systemGroup_Test_S_C](world)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("ordering cycle should panic at startup")
+		}
+	}()
+	world.Startup()
+}
+
+// 自定义Order的系统组按Order从小到大插入
+func TestSystemGroup_OrderGroups(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemGroup_Test_S_A](world, Order(20))
+	RegisterSystem[__systemGroup_Test_S_B](world, Order(10))
+	RegisterSystem[__systemGroup_Test_S_C](world, Order(30))
+	world.Startup()
+
+	var orders []Order
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		orders = append(orders, sg.order)
+	}
+	want := []Order{OrderFront, 10, 20, 30, OrderAppend}
+	if !reflect.DeepEqual(orders, want) {
+		t.Errorf("orders want %v, got %v", want, orders)
+	}
+	world.Stop()
+}
@@ -0,0 +1,211 @@
+package ecs
+
+import (
+	"reflect"
+	"time"
+)
+
+// SystemOption 系统选项，在注册系统时或在Init中通过SetOptions指定
+type SystemOption interface {
+	apply(sys ISystem)
+}
+
+func (o Order) apply(sys ISystem) {
+	sys.setOrder(o)
+}
+
+// 先后约束的目标为系统类型或系统集合
+type systemOrdering struct {
+	typ   reflect.Type
+	set   string
+	after bool
+}
+
+func (o systemOrdering) apply(sys ISystem) {
+	sys.addOrdering(o)
+}
+
+func (o systemOrdering) match(sys ISystem) bool {
+	if o.typ != nil {
+		return sys.Type() == o.typ
+	}
+	for _, name := range sys.getSets() {
+		if name == o.set {
+			return true
+		}
+	}
+	return false
+}
+
+func (o systemOrdering) String() string {
+	if o.typ != nil {
+		return o.typ.Name()
+	}
+	return "set " + o.set
+}
+
+// After 系统在T之后执行，仅约束同一阶段中Order相同的系统，与组件冲突一起决定批次
+func After[T SystemObject]() SystemOption {
+	return systemOrdering{typ: TypeOf[T](), after: true}
+}
+
+// Before 系统在T之前执行
+func Before[T SystemObject]() SystemOption {
+	return systemOrdering{typ: TypeOf[T]()}
+}
+
+// AfterSet 系统在集合name中的所有系统之后执行
+func AfterSet(name string) SystemOption {
+	return systemOrdering{set: name, after: true}
+}
+
+// BeforeSet 系统在集合name中的所有系统之前执行
+func BeforeSet(name string) SystemOption {
+	return systemOrdering{set: name}
+}
+
+type intervalOption time.Duration
+
+func (o intervalOption) apply(sys ISystem) {
+	schedule := sys.getSchedule()
+	schedule.interval = time.Duration(o)
+	schedule.step = 0
+}
+
+// Interval 系统更新阶段的执行间隔，距上次执行的时间达到间隔后执行一次，Event.Delta为距上次执行经过的时间
+func Interval(interval time.Duration) SystemOption {
+	return intervalOption(interval)
+}
+
+type fixedStepOption struct {
+	step     time.Duration
+	maxSteps int
+}
+
+func (o fixedStepOption) apply(sys ISystem) {
+	schedule := sys.getSchedule()
+	schedule.step = o.step
+	schedule.maxSteps = o.maxSteps
+	schedule.interval = 0
+}
+
+// FixedStep 固定步长执行系统更新阶段，累积世界经过的时间，每满一个步长执行一次，一帧内最多追赶maxSteps次，
+// 超出的部分被丢弃，Event.Delta固定为步长
+func FixedStep(step time.Duration, maxSteps int) SystemOption {
+	if maxSteps <= 0 {
+		maxSteps = 1
+	}
+	return fixedStepOption{step: step, maxSteps: maxSteps}
+}
+
+// RunCondition 系统的执行条件，在主线程中于系统每次派发前求值，可以观察到本帧之前批次的执行结果
+type RunCondition func(sys ISystem, event Event) bool
+
+type runIfOption []RunCondition
+
+func (o runIfOption) apply(sys ISystem) {
+	schedule := sys.getSchedule()
+	schedule.conditions = append(schedule.conditions, o...)
+}
+
+// RunIf 所有条件都满足时系统才执行本帧的更新阶段，可以多次指定，条件不满足时不会产生任何调度开销
+func RunIf(conditions ...RunCondition) SystemOption {
+	return runIfOption(conditions)
+}
+
+// EveryNFrames 每n帧执行一次
+func EveryNFrames(n uint64) RunCondition {
+	return func(sys ISystem, event Event) bool {
+		return n == 0 || event.Frame%n == 0
+	}
+}
+
+// ComponentExists 世界中存在组件T时执行，包括Free组件
+func ComponentExists[T ComponentObject]() RunCondition {
+	return func(sys ISystem, event Event) bool {
+		world := sys.World().base()
+		typ := TypeOf[T]()
+		if !world.componentMeta.Exist(typ) {
+			return false
+		}
+		set := world.getComponentSet(typ)
+		return set != nil && set.Len() > 0
+	}
+}
+
+// 系统的执行节奏，每帧开始时累积经过的时间，首次满足条件派发时计算本帧更新阶段的执行次数
+type systemSchedule struct {
+	interval    time.Duration
+	step        time.Duration
+	maxSteps    int
+	elapsed     time.Duration
+	started     bool
+	active      bool
+	decided     bool
+	runs        int
+	delta       time.Duration
+	conditions  []RunCondition
+	sets        []*systemSet
+	panicPolicy SystemPanicPolicy
+	panics      int
+}
+
+func (s *systemSchedule) check(sys ISystem, event Event) bool {
+	for _, cond := range s.conditions {
+		if !cond(sys, event) {
+			return false
+		}
+	}
+	return true
+}
+
+// 条件不满足时仍然累积经过的时间，条件满足后按节奏补上
+func (s *systemSchedule) prepare(event Event) {
+	s.active = false
+	s.decided = false
+	// 集合暂停期间不执行，也不累积时间
+	for _, set := range s.sets {
+		if set.paused {
+			return
+		}
+	}
+	s.active = true
+	if s.interval > 0 || s.step > 0 {
+		s.elapsed += event.Delta
+	}
+}
+
+// 更新阶段的系统派发前在主线程中调用，条件每次派发前求值，执行次数每帧只计算一次，
+// 同一帧的各更新阶段执行相同的次数
+func (s *systemSchedule) dispatch(sys ISystem, event Event) (int, time.Duration) {
+	if !s.active || !s.check(sys, event) {
+		return 0, 0
+	}
+	if s.decided {
+		return s.runs, s.delta
+	}
+	s.decided = true
+	switch {
+	case s.interval > 0:
+		s.runs = 0
+		if !s.started || s.elapsed >= s.interval {
+			s.runs = 1
+			s.delta = s.elapsed
+			s.elapsed = 0
+			s.started = true
+		}
+	case s.step > 0:
+		s.runs = int(s.elapsed / s.step)
+		if s.runs > s.maxSteps {
+			s.runs = s.maxSteps
+			s.elapsed %= s.step
+		} else {
+			s.elapsed -= time.Duration(s.runs) * s.step
+		}
+		s.delta = s.step
+	default:
+		s.runs = 1
+		s.delta = event.Delta
+	}
+	return s.runs, s.delta
+}
@@ -0,0 +1,168 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type __systemOption_Test_S_Interval struct {
+	System[__systemOption_Test_S_Interval]
+	deltas []time.Duration
+}
+
+func (s *__systemOption_Test_S_Interval) Update(event Event) {
+	s.deltas = append(s.deltas, event.Delta)
+}
+
+type __systemOption_Test_S_Fixed struct {
+	System[__systemOption_Test_S_Fixed]
+	preRuns int
+	runs    int
+	delta   time.Duration
+}
+
+func (s *__systemOption_Test_S_Fixed) Init(si SystemInitConstraint) error {
+	s.SetOptions(si, FixedStep(time.Millisecond*20, 3))
+	return nil
+}
+
+func (s *__systemOption_Test_S_Fixed) PreUpdate(event Event) {
+	s.preRuns++
+}
+
+func (s *__systemOption_Test_S_Fixed) Update(event Event) {
+	s.runs++
+	s.delta = event.Delta
+}
+
+func TestSystemOption_Schedule(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemOption_Test_S_Interval](world, Interval(time.Millisecond*100), Order(10))
+	RegisterSystem[__systemOption_Test_S_Fixed](world)
+	world.Startup()
+
+	s, _ := world.getSystem(TypeOf[__systemOption_Test_S_Interval]())
+	interval := s.(*__systemOption_Test_S_Interval)
+	s, _ = world.getSystem(TypeOf[__systemOption_Test_S_Fixed]())
+	fixed := s.(*__systemOption_Test_S_Fixed)
+	if interval.Order() != 10 {
+		t.Errorf("order option want 10, got %d", interval.Order())
+	}
+
+	for _, delta := range []time.Duration{0, 50, 50, 200} {
+		world.delta = delta * time.Millisecond
+		world.Update()
+	}
+
+	want := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond}
+	if !reflect.DeepEqual(interval.deltas, want) {
+		t.Errorf("interval deltas want %v, got %v", want, interval.deltas)
+	}
+	// 0ms:0次，50ms:2次余10ms，50ms:3次，200ms:追赶上限3次
+	if fixed.runs != 8 || fixed.preRuns != 8 {
+		t.Errorf("fixed step runs want 8, got %d %d", fixed.runs, fixed.preRuns)
+	}
+	if fixed.delta != time.Millisecond*20 {
+		t.Errorf("fixed step delta want 20ms, got %v", fixed.delta)
+	}
+	world.Stop()
+}
+
+type __systemOption_Test_C_State struct {
+	FreeComponent[__systemOption_Test_C_State]
+}
+
+type __systemOption_Test_S_Cond struct {
+	System[__systemOption_Test_S_Cond]
+	frames []uint64
+}
+
+func (s *__systemOption_Test_S_Cond) Update(event Event) {
+	s.frames = append(s.frames, event.Frame)
+}
+
+func TestSystemOption_RunIf(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterComponent[__systemOption_Test_C_State](world)
+	enabled := true
+	RegisterSystem[__systemOption_Test_S_Cond](world,
+		RunIf(EveryNFrames(2), ComponentExists[__systemOption_Test_C_State]()),
+		RunIf(func(sys ISystem, event Event) bool { return enabled }))
+	world.Startup()
+
+	s, _ := world.getSystem(TypeOf[__systemOption_Test_S_Cond]())
+	sys := s.(*__systemOption_Test_S_Cond)
+
+	world.Update()
+	AddFreeComponent[__systemOption_Test_C_State](world, &__systemOption_Test_C_State{})
+	for i := 0; i < 4; i++ {
+		world.Update()
+	}
+	enabled = false
+	for i := 0; i < 2; i++ {
+		world.Update()
+	}
+
+	want := []uint64{2, 4}
+	if !reflect.DeepEqual(sys.frames, want) {
+		t.Errorf("run frames want %v, got %v", want, sys.frames)
+	}
+	world.Stop()
+}
+
+type __systemOption_Test_S_Toggle struct {
+	System[__systemOption_Test_S_Toggle]
+	open  bool
+	frame uint64
+}
+
+func (s *__systemOption_Test_S_Toggle) Update(event Event) {
+	if event.Frame == 3 {
+		s.open = true
+		s.frame = event.Frame
+	}
+}
+
+type __systemOption_Test_S_Gated struct {
+	System[__systemOption_Test_S_Gated]
+	frames []uint64
+}
+
+func (s *__systemOption_Test_S_Gated) Update(event Event) {
+	s.frames = append(s.frames, event.Frame)
+}
+
+// 条件在派发前求值，能观察到同一帧中之前批次的系统的修改
+func TestSystemOption_RunIfAtDispatch(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemOption_Test_S_Toggle](world, Order(10))
+	RegisterSystem[__systemOption_Test_S_Gated](world, Order(20),
+		RunIf(func(sys ISystem, event Event) bool {
+			s, _ := sys.World().base().getSystem(TypeOf[__systemOption_Test_S_Toggle]())
+			return s.(*__systemOption_Test_S_Toggle).open
+		}))
+	world.Startup()
+
+	for i := 0; i < 5; i++ {
+		world.Update()
+	}
+
+	s, _ := world.getSystem(TypeOf[__systemOption_Test_S_Toggle]())
+	toggle := s.(*__systemOption_Test_S_Toggle)
+	s, _ = world.getSystem(TypeOf[__systemOption_Test_S_Gated]())
+	gated := s.(*__systemOption_Test_S_Gated)
+	if len(gated.frames) == 0 || gated.frames[0] != toggle.frame {
+		t.Errorf("gated system should run in frame %d, got %v", toggle.frame, gated.frames)
+	}
+	world.Stop()
+}
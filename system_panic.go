@@ -0,0 +1,123 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
+
+// SystemPanicPolicy 系统回调panic后的处理策略
+type SystemPanicPolicy uint8
+
+const (
+	SystemPanicDefault SystemPanicPolicy = iota // 使用WorldConfig.PanicPolicy，均未指定时为SystemPanicBreak
+	SystemPanicBreak                            // 标记系统损坏，之后不再执行
+	SystemPanicRetry                            // 下一帧继续执行，连续panic达到WorldConfig.PanicRetryLimit次后标记损坏
+	SystemPanicCrash                            // 回调通知后在主线程中重新抛出panic
+)
+
+// SystemPanicInfo 系统panic的信息
+type SystemPanicInfo struct {
+	System reflect.Type
+	Frame  uint64
+	Stage  Stage
+	Err    error
+	Stack  []byte
+	Broken bool
+}
+
+type panicPolicyOption SystemPanicPolicy
+
+func (o panicPolicyOption) apply(sys ISystem) {
+	sys.getSchedule().panicPolicy = SystemPanicPolicy(o)
+}
+
+// OnPanic 指定系统的panic处理策略，覆盖WorldConfig.PanicPolicy
+func OnPanic(policy SystemPanicPolicy) SystemOption {
+	return panicPolicyOption(policy)
+}
+
+// 执行系统回调，捕获panic并按策略处理，在系统所在的协程中执行
+func (p *systemFlow) execute(sys ISystem, period Stage, fn func(event Event), e Event, runs int) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.recoverPanic(sys, period, e, r)
+		}
+	}()
+	for i := 0; i < runs; i++ {
+		fn(e)
+	}
+	sys.getSchedule().panics = 0
+}
+
+func (p *systemFlow) recoverPanic(sys ISystem, period Stage, e Event, r any) {
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+	info := SystemPanicInfo{
+		System: sys.Type(),
+		Frame:  e.Frame,
+		Stage:  period,
+		Err:    err,
+		Stack:  debug.Stack(),
+	}
+
+	schedule := sys.getSchedule()
+	schedule.panics++
+	switch p.panicPolicy(sys) {
+	case SystemPanicRetry:
+		if limit := p.world.config.PanicRetryLimit; limit > 0 && schedule.panics >= limit {
+			info.Broken = true
+		}
+	case SystemPanicCrash:
+	default:
+		info.Broken = true
+	}
+	if info.Broken {
+		sys.setBroken()
+	}
+
+	p.lock.Lock()
+	p.panics = append(p.panics, panicRecord{info: info, value: r})
+	p.lock.Unlock()
+}
+
+func (p *systemFlow) panicPolicy(sys ISystem) SystemPanicPolicy {
+	if policy := sys.getSchedule().panicPolicy; policy != SystemPanicDefault {
+		return policy
+	}
+	if policy := p.world.config.PanicPolicy; policy != SystemPanicDefault {
+		return policy
+	}
+	return SystemPanicBreak
+}
+
+type panicRecord struct {
+	info  SystemPanicInfo
+	value any
+}
+
+// 在主线程中记录日志并回调WorldConfig.PanicCallback，Crash策略的panic在回调后重新抛出
+func (p *systemFlow) reportPanics() {
+	p.lock.Lock()
+	panics := p.panics
+	p.panics = nil
+	p.lock.Unlock()
+
+	var crash *panicRecord
+	for i, record := range panics {
+		info := record.info
+		Log.Errorf("system %s panic at frame %d %s, broken: %v, %v\n%s",
+			info.System.Name(), info.Frame, info.Stage, info.Broken, info.Err, info.Stack)
+		if p.world.config.PanicCallback != nil {
+			p.world.config.PanicCallback(info)
+		}
+		if crash == nil && p.panicPolicy(p.systems[info.System]) == SystemPanicCrash {
+			crash = &panics[i]
+		}
+	}
+	if crash != nil {
+		panic(crash.value)
+	}
+}
@@ -0,0 +1,156 @@
+package ecs
+
+import "fmt"
+
+type setOption string
+
+func (o setOption) apply(sys ISystem) {
+	sys.addSet(string(o))
+}
+
+// InSet 将系统加入命名集合，一个系统可以加入多个集合
+func InSet(name string) SystemOption {
+	return setOption(name)
+}
+
+// 命名的系统集合，集合的选项在系统注册时应用到系统上
+type systemSet struct {
+	name    string
+	order   Order
+	options []SystemOption
+	systems []ISystem
+	paused  bool
+}
+
+func (p *systemFlow) getSet(name string) *systemSet {
+	set, ok := p.sets[name]
+	if !ok {
+		set = &systemSet{name: name}
+		p.sets[name] = set
+	}
+	return set
+}
+
+// 注册系统时加入其声明的集合，返回集合指定的Order
+func (p *systemFlow) joinSets(system ISystem) Order {
+	order := OrderInvalid
+	for _, name := range system.getSets() {
+		set := p.getSet(name)
+		set.systems = append(set.systems, system)
+		system.getSchedule().sets = append(system.getSchedule().sets, set)
+		for _, option := range set.options {
+			option.apply(system)
+		}
+		if set.order != OrderInvalid {
+			order = set.order
+		}
+	}
+	return order
+}
+
+// ConfigureSet 为集合指定选项，对集合中的所有系统生效，需在集合中的系统注册前调用。
+// 可用的选项有Order、RunIf、Interval、FixedStep、After、Before、AfterSet、BeforeSet，运行中调整Order使用ReorderSet
+func ConfigureSet(world IWorld, name string, options ...SystemOption) {
+	w := world.base()
+	w.checkMainThread()
+	if w.getStatus() != WorldStatusInitialized {
+		panic("system set configure only in world init")
+	}
+	set := w.systemFlow.getSet(name)
+	if len(set.systems) > 0 {
+		panic("configure system set " + name + " before registering its systems")
+	}
+	for _, option := range options {
+		if order, ok := option.(Order); ok {
+			set.order = order
+			continue
+		}
+		set.options = append(set.options, option)
+	}
+}
+
+func (w *ecsWorld) pauseSet(name string) {
+	w.systemFlow.controlSet(systemControl{set: name, op: systemControlPauseSet})
+}
+
+func (w *ecsWorld) resumeSet(name string) {
+	w.systemFlow.controlSet(systemControl{set: name, op: systemControlResumeSet})
+}
+
+func (w *ecsWorld) reorderSet(name string, order Order) {
+	w.systemFlow.controlSet(systemControl{set: name, order: order, op: systemControlReorderSet})
+}
+
+// 暂停、恢复集合，调整集合的Order，与系统的控制操作一起在下一帧开始的同步点按调用顺序生效
+func (p *systemFlow) controlSet(control systemControl) {
+	p.lock.Lock()
+	p.controls = append(p.controls, control)
+	p.lock.Unlock()
+}
+
+func (p *systemFlow) applySetControl(control systemControl) {
+	set := p.getSet(control.set)
+	switch control.op {
+	case systemControlPauseSet:
+		set.paused = true
+	case systemControlResumeSet:
+		set.paused = false
+	case systemControlReorderSet:
+		if err := p.reorderSet(set, control.order); err != nil {
+			Log.Error(err)
+		}
+	}
+}
+
+// 将集合中的系统移到order对应的系统组，与顺序约束矛盾时还原
+func (p *systemFlow) reorderSet(set *systemSet, order Order) error {
+	if order > OrderAppend {
+		Log.Errorf("system order must less then %d, resort order to %d", OrderAppend+1, OrderAppend)
+		order = OrderAppend
+	}
+	prev := set.order
+	orders := make([]Order, len(set.systems))
+	for i, sys := range set.systems {
+		orders[i] = sys.Order()
+		p.moveSystem(sys, order)
+	}
+	set.order = order
+	if err := p.checkOrdering(); err != nil {
+		for i, sys := range set.systems {
+			p.moveSystem(sys, orders[i])
+		}
+		set.order = prev
+		return fmt.Errorf("reorder system set %s failed, %w", set.name, err)
+	}
+	return nil
+}
+
+func (p *systemFlow) moveSystem(sys ISystem, order Order) {
+	p.removeFromStages(sys)
+	sys.reorder(order)
+	p.addToStages(sys, order)
+}
+
+// 回滚时需要恢复的集合状态，集合中系统的顺序随系统的运行状态恢复
+type systemSetState struct {
+	paused bool
+	order  Order
+}
+
+// 集合只增不减，按名字覆盖保存
+func (p *systemFlow) saveSets(states map[string]systemSetState) {
+	for name, set := range p.sets {
+		states[name] = systemSetState{paused: set.paused, order: set.order}
+	}
+}
+
+func (p *systemFlow) loadSets(states map[string]systemSetState) {
+	for name, set := range p.sets {
+		if state, ok := states[name]; ok {
+			set.paused = state.paused
+			set.order = state.order
+		} else {
+			set.paused = false
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type __systemSet_Test_S_1 struct {
+	System[__systemSet_Test_S_1]
+	runs int
+}
+
+func (s *__systemSet_Test_S_1) Update(event Event) {
+	s.runs++
+}
+
+type __systemSet_Test_S_2 struct {
+	System[__systemSet_Test_S_2]
+	runs int
+}
+
+func (s *__systemSet_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetOptions(si, InSet("combat"))
+	return nil
+}
+
+func (s *__systemSet_Test_S_2) Update(event Event) {
+	s.runs++
+}
+
+type __systemSet_Test_S_3 struct {
+	System[__systemSet_Test_S_3]
+	runs int
+}
+
+func (s *__systemSet_Test_S_3) Update(event Event) {
+	s.runs++
+}
+
+func TestSystemSet(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	enabled := true
+	ConfigureSet(world, "combat", Order(5), RunIf(func(sys ISystem, event Event) bool { return enabled }))
+	RegisterSystem[__systemSet_Test_S_3](world, Order(5), AfterSet("combat"))
+	RegisterSystem[__systemSet_Test_S_1](world, InSet("combat"))
+	RegisterSystem[__systemSet_Test_S_2](world)
+	world.Startup()
+
+	s, _ := world.getSystem(TypeOf[__systemSet_Test_S_1]())
+	s1 := s.(*__systemSet_Test_S_1)
+	s, _ = world.getSystem(TypeOf[__systemSet_Test_S_2]())
+	s2 := s.(*__systemSet_Test_S_2)
+	s, _ = world.getSystem(TypeOf[__systemSet_Test_S_3]())
+	s3 := s.(*__systemSet_Test_S_3)
+	if s1.Order() != 5 || s2.Order() != 5 {
+		t.Errorf("set order want 5, got %d %d", s1.Order(), s2.Order())
+	}
+
+	var batches [][]string
+	var sg *SystemGroup
+	for _, group := range world.systemFlow.stages[StageUpdate] {
+		if group.order == 5 {
+			sg = group
+		}
+	}
+	for ss := sg.Begin(); !sg.End(); ss = sg.Next() {
+		var names []string
+		for _, s := range ss {
+			names = append(names, s.Type().Name())
+		}
+		batches = append(batches, names)
+	}
+	want := [][]string{{"__systemSet_Test_S_1", "__systemSet_Test_S_2"}, {"__systemSet_Test_S_3"}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches want %v, got %v", want, batches)
+	}
+
+	world.Update()
+	world.PauseSet("combat")
+	world.Update()
+	world.ResumeSet("combat")
+	world.Update()
+	enabled = false
+	world.Update()
+	if s1.runs != 2 || s2.runs != 2 || s3.runs != 4 {
+		t.Errorf("runs want 2 2 4, got %d %d %d", s1.runs, s2.runs, s3.runs)
+	}
+	world.Stop()
+}
+
+func TestSystemSet_Reorder(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	ConfigureSet(world, "combat", Order(5))
+	RegisterSystem[__systemSet_Test_S_3](world, Order(5), AfterSet("combat"))
+	RegisterSystem[__systemSet_Test_S_1](world, InSet("combat"))
+	RegisterSystem[__systemSet_Test_S_2](world)
+	world.Startup()
+	world.Update()
+
+	orders := func() map[string]Order {
+		m := map[string]Order{}
+		for _, group := range world.systemFlow.stages[StageUpdate] {
+			for _, sys := range group.all() {
+				m[sys.Type().Name()] = group.order
+			}
+		}
+		return m
+	}
+
+	// 下一帧开始时生效
+	world.ReorderSet("combat", Order(1))
+	if got := orders()["__systemSet_Test_S_1"]; got != 5 {
+		t.Errorf("reorder should wait for the sync point, got order %d", got)
+	}
+	world.Update()
+	want := map[string]Order{"__systemSet_Test_S_1": 1, "__systemSet_Test_S_2": 1, "__systemSet_Test_S_3": 5}
+	if got := orders(); !reflect.DeepEqual(got, want) {
+		t.Errorf("orders want %v, got %v", want, got)
+	}
+
+	// 与AfterSet矛盾，保持原顺序
+	world.ReorderSet("combat", Order(10))
+	world.Update()
+	if got := orders(); !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicting reorder should be reverted, want %v, got %v", want, got)
+	}
+
+	s, _ := world.getSystem(TypeOf[__systemSet_Test_S_1]())
+	s1 := s.(*__systemSet_Test_S_1)
+	s, _ = world.getSystem(TypeOf[__systemSet_Test_S_3]())
+	s3 := s.(*__systemSet_Test_S_3)
+	if s1.runs != 3 || s3.runs != 3 || s1.Order() != 1 {
+		t.Errorf("runs want 3 3 and order 1, got %d %d %d", s1.runs, s3.runs, s1.Order())
+	}
+	world.Stop()
+}
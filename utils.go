@@ -2,6 +2,7 @@ package ecs
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"reflect"
 	"runtime/debug"
@@ -15,6 +16,7 @@ var Empty struct{} = struct{}{}
 var seq uint32
 var timestamp int64
 
+// DebugTry 为true时Try、TryAndReport不捕获panic，便于调试时定位
 var DebugTry = true
 
 func init() {
@@ -51,6 +53,8 @@ func Try(task func(), catch ...func(error)) {
 				str = r.(error).Error()
 			case string:
 				str = r.(string)
+			default:
+				str = fmt.Sprint(r)
 			}
 			err := errors.New(str + "\n" + string(debug.Stack()))
 			if len(catch) > 0 {
@@ -67,18 +71,17 @@ func TryAndReport(task func() error) (err error) {
 			return
 		}
 		if r := recover(); r != nil {
-			switch typ := r.(type) {
+			switch r.(type) {
 			case error:
 				err = r.(error)
 			case string:
 				err = errors.New(r.(string))
 			default:
-				_ = typ
+				err = fmt.Errorf("%v", r)
 			}
 		}
 	}()
-	err = task()
-	return nil
+	return task()
 }
 
 func IsPureValueType(typ reflect.Type) bool {
@@ -3,6 +3,7 @@ package ecs
 import (
 	"reflect"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -25,11 +26,17 @@ type WorldConfig struct {
 	IsMetricsPrint     bool
 	CpuNum             int    //使用的最大cpu数量
 	MaxPoolThread      uint32 //线程池最大线程数量
-	MaxPoolJobQueue    uint32 //线程池最大任务队列长度
+	MaxPoolJobQueue    uint32 //线程池每个线程的任务积压上限，只限制TryAdd，Add和框架内部的批量投递不受限制
 	HashCount          int    //容器桶数量
 	CollectionVersion  int
 	FrameInterval      time.Duration //帧间隔
+	HistoryFrames      int           //变更记录保留的帧数
 	StopCallback       func(world *ecsWorld)
+	PanicPolicy        SystemPanicPolicy          //系统panic的默认处理策略
+	PanicRetryLimit    int                        //SystemPanicRetry策略下连续panic的次数上限，0为不限制
+	PanicCallback      func(info SystemPanicInfo) //系统panic后在主线程中回调
+	Deterministic      bool                       //确定性模式，所有阶段在主线程中按固定顺序执行，帧间隔固定为FrameInterval
+	RollbackFrames     int                        //内存中保留用于回滚的帧数，0为不保留
 }
 
 func NewDefaultWorldConfig() *WorldConfig {
@@ -44,6 +51,7 @@ func NewDefaultWorldConfig() *WorldConfig {
 		MaxPoolJobQueue:    10,
 		HashCount:          runtime.NumCPU() * 4,
 		FrameInterval:      time.Millisecond * 33,
+		HistoryFrames:      300,
 	}
 }
 
@@ -87,9 +95,26 @@ type ecsWorld struct {
 	idGenerator     *EntityIDGenerator
 	componentMeta   *componentMeta
 	utilities       map[reflect.Type]IUtility
+	events          map[reflect.Type]iEventChannel
+	commands        map[string]reflect.Type
+	recorder        *recorder
+	rollback        *rollbackBuffer
+	parentMeta      *ComponentMetaInfo
+	childrenMeta    *ComponentMetaInfo
+	hierarchyOps    []hierarchyOp
+	hierarchyLock   sync.Mutex
+	relations       map[reflect.Type]iRelationStore
+	relationOps     []func()
+	relationLock    sync.Mutex
+	released        []Entity
+	prefabs         prefabRegistry
 	workPool        *Pool
 	metrics         *Metrics
 	frame           uint64
+	tick            uint32
+	frameTicks      []frameTick
+	entityLog       []entityRecord
+	journalStart    uint64
 	ts              time.Time
 	delta           time.Duration
 	pureUpdateDelta time.Duration
@@ -116,7 +141,11 @@ func (w *ecsWorld) init(config *WorldConfig) *ecsWorld {
 	w.idGenerator = NewEntityIDGenerator(1024, 10)
 
 	w.componentMeta = NewComponentMeta(w)
+	w.initHierarchy()
 	w.utilities = make(map[reflect.Type]IUtility)
+	w.events = make(map[reflect.Type]iEventChannel)
+	w.commands = make(map[string]reflect.Type)
+	w.relations = make(map[reflect.Type]iRelationStore)
 
 	w.metrics = NewMetrics(w.config.IsMetrics, w.config.IsMetricsPrint)
 
@@ -127,6 +156,10 @@ func (w *ecsWorld) init(config *WorldConfig) *ecsWorld {
 		w.config.FrameInterval = time.Millisecond * 33
 	}
 
+	if w.config.HistoryFrames <= 0 {
+		w.config.HistoryFrames = 300
+	}
+
 	if w.config.HashCount == 0 {
 		w.config.HashCount = config.CpuNum
 	}
@@ -134,6 +167,12 @@ func (w *ecsWorld) init(config *WorldConfig) *ecsWorld {
 	sf := newSystemFlow(w)
 	w.systemFlow = sf
 
+	w.resetJournal()
+
+	if w.config.RollbackFrames > 0 {
+		w.rollback = newRollbackBuffer(w.config.RollbackFrames)
+	}
+
 	w.setStatus(WorldStatusInitialized)
 
 	return w
@@ -156,6 +195,10 @@ func (w *ecsWorld) startup() {
 		panic("world is not initialized or already running.")
 	}
 
+	if err := w.systemFlow.checkOrdering(); err != nil {
+		panic(err)
+	}
+
 	if w.config.MetaInfoDebugPrint || w.config.Debug {
 		w.systemFlow.SystemInfoPrint()
 		w.componentMeta.ComponentMetaInfoPrint()
@@ -164,6 +207,7 @@ func (w *ecsWorld) startup() {
 	w.switchMainThread()
 	w.workPool.Start()
 	w.setStatus(WorldStatusRunning)
+	w.saveFrameState()
 }
 
 func (w *ecsWorld) update() {
@@ -174,7 +218,11 @@ func (w *ecsWorld) update() {
 	if w.status != WorldStatusRunning {
 		panic("world is not running, must startup first.")
 	}
+	w.recordFrame()
 	e := Event{Delta: w.delta, Frame: w.frame}
+	if w.config.Deterministic {
+		e.Delta = w.config.FrameInterval
+	}
 	start := time.Now()
 	w.systemFlow.run(e)
 	now := time.Now()
@@ -182,6 +230,9 @@ func (w *ecsWorld) update() {
 	w.pureUpdateDelta = now.Sub(start)
 	w.ts = now
 	w.frame++
+	w.beginFrameTick()
+	w.trimJournal()
+	w.saveFrameState()
 }
 
 func (w *ecsWorld) optimize(t time.Duration, force bool) {
@@ -241,6 +292,14 @@ func (w *ecsWorld) addJob(job func(), hashKey ...uint32) {
 	w.workPool.Add(job, hashKey...)
 }
 
+func (w *ecsWorld) addJobs(jobs []func()) {
+	w.workPool.AddBatch(jobs)
+}
+
+func (w *ecsWorld) addWorkerJobs(jobs []func(worker *Worker)) {
+	w.workPool.addBatch(jobs)
+}
+
 func (w *ecsWorld) addEntity(info EntityInfo) *EntityInfo {
 	return w.entities.Add(info)
 }
@@ -249,8 +308,28 @@ func (w *ecsWorld) getEntityInfo(entity Entity) (*EntityInfo, bool) {
 	return w.entities.GetEntityInfo(entity)
 }
 
+// IsAlive 实体存在且未被销毁，实体销毁后ID被复用时，旧的Entity返回false。AsyncWorld需在Sync中调用
+func (w *ecsWorld) IsAlive(entity Entity) bool {
+	return w.entities.Exist(entity)
+}
+
 func (w *ecsWorld) deleteEntity(entity Entity) {
-	w.entities.Remove(entity)
+	if w.entities.Remove(entity) != nil {
+		w.recordEntity(entity, true)
+		w.released = append(w.released, entity)
+	}
+}
+
+// 同步点，销毁的实体的组件已移除，清理关系后回收ID
+func (w *ecsWorld) freeEntities() {
+	if len(w.released) == 0 {
+		return
+	}
+	w.releaseEntities()
+	for _, entity := range w.released {
+		w.idGenerator.FreeID(entity)
+	}
+	w.released = w.released[:0]
 }
 
 func (w *ecsWorld) getComponentSet(typ reflect.Type) IComponentSet {
@@ -279,6 +358,7 @@ func (w *ecsWorld) getOrCreateComponentMetaInfo(component IComponent) *Component
 
 func (w *ecsWorld) newEntity() *EntityInfo {
 	info := EntityInfo{entity: w.idGenerator.NewID(), compound: NewCompound(4)}
+	w.recordEntity(info.entity, false)
 	return w.addEntity(info)
 }
 
@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"io"
 	"sync"
 	"time"
 )
@@ -14,7 +15,12 @@ func (g SyncWrapper) getWorld() IWorld {
 }
 
 func (g SyncWrapper) NewEntity() Entity {
-	return g.getWorld().newEntity().Entity()
+	w := g.getWorld().base()
+	entity := w.newEntity().Entity()
+	if r := w.recording(); r != nil {
+		r.entity(recordKindNewEntity, w.frame, entity)
+	}
+	return entity
 }
 
 func (g SyncWrapper) DestroyEntity(entity Entity) {
@@ -22,6 +28,9 @@ func (g SyncWrapper) DestroyEntity(entity Entity) {
 	if !ok {
 		return
 	}
+	if w := g.getWorld().base(); w.recording() != nil {
+		w.recorder.entity(recordKindDestroyEntity, w.frame, entity)
+	}
 	info.Destroy(*g.world)
 }
 
@@ -30,6 +39,9 @@ func (g SyncWrapper) Add(entity Entity, components ...IComponent) {
 	if !ok {
 		return
 	}
+	if w := g.getWorld().base(); w.recording() != nil {
+		w.recorder.components(recordKindAdd, w.frame, entity, components)
+	}
 	info.Add(*g.world, components...)
 }
 
@@ -38,12 +50,95 @@ func (g SyncWrapper) Remove(entity Entity, components ...IComponent) {
 	if !ok {
 		return
 	}
+	if w := g.getWorld().base(); w.recording() != nil {
+		w.recorder.components(recordKindRemove, w.frame, entity, components)
+	}
 	info.Remove(*g.world, components...)
 }
 
+// IsAlive 实体存在且未被销毁
+func (g SyncWrapper) IsAlive(entity Entity) bool {
+	return g.getWorld().base().IsAlive(entity)
+}
+
+// PauseSet 暂停集合中所有系统的更新阶段，参考SyncWorld.PauseSet
+func (g SyncWrapper) PauseSet(name string) {
+	w := g.getWorld().base()
+	if r := w.recording(); r != nil {
+		r.set(recordKindPauseSet, w.frame, name)
+	}
+	w.pauseSet(name)
+}
+
+// ResumeSet 恢复集合中所有系统的更新阶段
+func (g SyncWrapper) ResumeSet(name string) {
+	w := g.getWorld().base()
+	if r := w.recording(); r != nil {
+		r.set(recordKindResumeSet, w.frame, name)
+	}
+	w.resumeSet(name)
+}
+
+// ReorderSet 调整集合的Order，参考SyncWorld.ReorderSet
+func (g SyncWrapper) ReorderSet(name string, order Order) {
+	w := g.getWorld().base()
+	if r := w.recording(); r != nil {
+		r.reorderSet(w.frame, name, order)
+	}
+	w.reorderSet(name, order)
+}
+
+// SetParent 将child挂到parent下，下一帧生效，parent为0时解除父子关系
+func (g SyncWrapper) SetParent(child Entity, parent Entity) {
+	w := g.getWorld().base()
+	if r := w.recording(); r != nil {
+		r.setParent(w.frame, child, parent)
+	}
+	SetParent(w, child, parent)
+}
+
+// LoadPrefabs 从JSON数组加载实体模板，参考SyncWorld.LoadPrefabs
+func (g SyncWrapper) LoadPrefabs(reader io.Reader) error {
+	return g.getWorld().base().loadPrefabs(reader)
+}
+
+// Spawn 按模板创建实体，overrides整体覆盖模板中同类型的组件
+func (g SyncWrapper) Spawn(prefab string, overrides ...IComponent) (Entity, error) {
+	entities, err := g.SpawnN(prefab, 1, overrides...)
+	if err != nil {
+		return 0, err
+	}
+	return entities[0], nil
+}
+
+// SpawnN 按模板批量创建n个实体
+func (g SyncWrapper) SpawnN(prefab string, n int, overrides ...IComponent) ([]Entity, error) {
+	components, err := g.getWorld().base().resolvePrefab(prefab)
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+	entities := make([]Entity, n)
+	for i := range entities {
+		entities[i] = g.NewEntity()
+		g.Add(entities[i], instantiatePrefab(components, overrides)...)
+	}
+	return entities, nil
+}
+
+// Execute 立即执行命令，记录时命令会被完整记录
+func (g SyncWrapper) Execute(cmd Command) error {
+	return g.getWorld().base().execute(g, cmd)
+}
+
+// Merge 将快照合并到运行中的世界，参考SyncWorld.Merge
+func (g SyncWrapper) Merge(reader io.Reader) (map[Entity]Entity, error) {
+	return g.getWorld().base().merge(reader)
+}
+
 type syncTask struct {
 	wait chan struct{}
 	fn   func(wrapper SyncWrapper) error
+	cmd  Command
 }
 
 type AsyncWorld struct {
@@ -80,6 +175,9 @@ func (w *AsyncWorld) Startup() {
 				}
 				w.systemFlow.stop()
 				w.workPool.Release()
+				if err := w.stopRecord(); err != nil {
+					Log.Error(err)
+				}
 				return
 			default:
 			}
@@ -95,6 +193,11 @@ func (w *AsyncWorld) Startup() {
 	}()
 }
 
+// PoolStats 协程池中各worker的运行统计
+func (w *AsyncWorld) PoolStats() []WorkerStats {
+	return w.workPool.Stats()
+}
+
 func (w *AsyncWorld) Stop() {
 	w.wStop <- struct{}{}
 }
@@ -108,6 +211,9 @@ func (w *AsyncWorld) dispatch() {
 	gaw.world = &ig
 	for _, task := range w.syncQueue {
 		err := TryAndReport(func() error {
+			if task.cmd != nil {
+				return w.execute(gaw, task.cmd)
+			}
 			return task.fn(gaw)
 		})
 		if err != nil {
@@ -143,3 +249,36 @@ func (w *AsyncWorld) Wait(fn func(g SyncWrapper) error) {
 	w.lock.Unlock()
 	<-wait
 }
+
+// SyncCommand 提交命令，在下一帧开始前执行，记录时命令会被完整记录
+func (w *AsyncWorld) SyncCommand(cmd Command) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.syncQueue = append(w.syncQueue, syncTask{
+		wait: nil,
+		cmd:  cmd,
+	})
+}
+
+// WaitCommand 提交命令并等待执行完成
+func (w *AsyncWorld) WaitCommand(cmd Command) {
+	w.lock.Lock()
+	wait := make(chan struct{})
+	w.syncQueue = append(w.syncQueue, syncTask{
+		wait: wait,
+		cmd:  cmd,
+	})
+	w.lock.Unlock()
+	<-wait
+}
+
+// RegisterPrefab 注册实体模板，同名模板会被替换，可以在任意协程中调用
+func (w *AsyncWorld) RegisterPrefab(prefab *Prefab) error {
+	return w.registerPrefab(prefab)
+}
+
+// Record 开始记录世界的外部输入，只能在Startup前调用，世界停止时结束记录
+func (w *AsyncWorld) Record(writer io.Writer) error {
+	return w.startRecord(writer)
+}
@@ -0,0 +1,253 @@
+package ecs
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	diffMagic   = "ECSD"
+	diffVersion = uint16(1)
+)
+
+// 增量格式：头部(magic, version, since, frame) -> EntityID生成器 -> 组件类型表 -> 销毁的实体 -> 创建的实体 ->
+// 移除的组件 -> 新增或修改的组件。自由组件没有移除记录，按容器整体替换。
+func (w *ecsWorld) diff(since uint64, out io.Writer) error {
+	w.checkMainThread()
+
+	stamp, ok := w.tickOfFrame(since)
+	if !ok {
+		return fmt.Errorf("diff since frame %d is out of history, earliest frame is %d", since, w.journalStart)
+	}
+
+	b := newBinaryWriter(out)
+	b.write([]byte(diffMagic))
+	b.writeUint16(diffVersion)
+	b.writeUint64(since)
+	b.writeUint64(w.frame)
+
+	encodeIDGenerator(b, w.idGenerator)
+
+	w.writeTypeTable(b)
+
+	// 同一实体以最后一条记录为准
+	last := map[Entity]bool{}
+	var order []Entity
+	w.rangeEntityLog(stamp, func(record entityRecord) bool {
+		if _, ok := last[record.entity]; !ok {
+			order = append(order, record.entity)
+		}
+		last[record.entity] = record.destroyed
+		return true
+	})
+	var destroyed, created []Entity
+	for _, entity := range order {
+		if last[entity] {
+			destroyed = append(destroyed, entity)
+		} else if _, ok := w.getEntityInfo(entity); ok {
+			created = append(created, entity)
+		}
+	}
+	writeEntities(b, destroyed)
+	writeEntities(b, created)
+
+	type removedSet struct {
+		it       uint16
+		entities []Entity
+	}
+	var removed []removedSet
+	var changed []snapshotSet
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		meta := (*set).GetElementMeta()
+		if meta.componentType&ComponentTypeDisposableMask > 0 {
+			return true
+		}
+		if meta.componentType&ComponentTypeFreeMask > 0 {
+			elements := make([]IComponent, 0, (*set).Len())
+			(*set).Range(func(com IComponent) bool {
+				elements = append(elements, com)
+				return true
+			})
+			changed = append(changed, snapshotSet{meta: meta, elements: elements})
+			return true
+		}
+
+		r := removedSet{it: meta.it}
+		(*set).rangeRemoved(stamp, func(entity Entity) bool {
+			r.entities = append(r.entities, entity)
+			return true
+		})
+		if len(r.entities) > 0 {
+			removed = append(removed, r)
+		}
+
+		c := snapshotSet{meta: meta}
+		(*set).Range(func(com IComponent) bool {
+			if !tickBefore(com.getChangedTick(), stamp) {
+				c.elements = append(c.elements, com)
+			}
+			return true
+		})
+		if len(c.elements) > 0 {
+			changed = append(changed, c)
+		}
+		return true
+	})
+
+	b.writeUint16(uint16(len(removed)))
+	for _, r := range removed {
+		b.writeUint16(r.it)
+		writeEntities(b, r.entities)
+	}
+
+	b.writeUint16(uint16(len(changed)))
+	for _, c := range changed {
+		writeSnapshotSet(b, c.meta.it, c.elements)
+	}
+
+	return b.flush()
+}
+
+// 将增量应用到当前世界，需在帧间调用。增量的起始帧必须等于世界当前帧，且世界在加载快照或上一个增量后
+// 没有在本地创建、销毁实体（ID由增量中的生成器整体替换，本地分配的ID会被重复使用），否则返回错误
+func (w *ecsWorld) applyDiff(in io.Reader) error {
+	w.checkMainThread()
+
+	b := newBinaryReader(in)
+	magic := make([]byte, len(diffMagic))
+	b.read(magic)
+	if b.err != nil || string(magic) != diffMagic {
+		return ErrSnapshotInvalid
+	}
+	if b.readUint16() != diffVersion {
+		return ErrSnapshotVersion
+	}
+	since := b.readUint64()
+	frame := b.readUint64()
+	if b.err != nil {
+		return b.err
+	}
+	if since != w.frame {
+		return fmt.Errorf("diff since frame %d does not match world frame %d", since, w.frame)
+	}
+	if w.idGenerator.changes != 0 {
+		return ErrDiffLocalEntities
+	}
+	generator := decodeIDGenerator(b)
+
+	metas, err := w.readTypeTable(b)
+	if err != nil {
+		return err
+	}
+
+	destroyed := readEntities(b)
+	created := readEntities(b)
+
+	removed := map[*ComponentMetaInfo][]Entity{}
+	var removedOrder []*ComponentMetaInfo
+	count := int(b.readUint16())
+	for i := 0; i < count && b.err == nil; i++ {
+		meta, ok := metas[b.readUint16()]
+		if !ok {
+			return ErrSnapshotInvalid
+		}
+		removed[meta] = readEntities(b)
+		removedOrder = append(removedOrder, meta)
+	}
+
+	var changed []snapshotSet
+	count = int(b.readUint16())
+	for i := 0; i < count && b.err == nil; i++ {
+		set, ok := readSnapshotSet(b, metas)
+		if !ok {
+			return ErrSnapshotInvalid
+		}
+		changed = append(changed, set)
+	}
+	if b.err != nil {
+		return b.err
+	}
+
+	// 数据完整读取后再修改世界
+	for _, entity := range destroyed {
+		info, ok := w.getEntityInfo(entity)
+		if !ok {
+			continue
+		}
+		for _, it := range info.compound {
+			if set := w.getComponentSetByIntType(it); set != nil {
+				set.removeAndRecord(entity, w.currentTick())
+			}
+		}
+		w.deleteEntity(entity)
+	}
+
+	for _, meta := range removedOrder {
+		set := w.getComponentSetByIntType(meta.it)
+		if set == nil {
+			continue
+		}
+		for _, entity := range removed[meta] {
+			if set.removeAndRecord(entity, w.currentTick()) != nil {
+				if info, ok := w.getEntityInfo(entity); ok {
+					info.removeFromCompound(meta.it)
+				}
+			}
+		}
+	}
+
+	for _, entity := range created {
+		if _, ok := w.getEntityInfo(entity); ok {
+			continue
+		}
+		w.addEntity(EntityInfo{entity: entity, compound: NewCompound(4)})
+		w.recordEntity(entity, false)
+	}
+
+	for _, set := range changed {
+		if set.meta.componentType&ComponentTypeFreeMask > 0 {
+			if s := w.getComponentSetByIntType(set.meta.it); s != nil {
+				s.Clear()
+			}
+			for _, com := range set.elements {
+				w.restoreComponent(0, com)
+			}
+			continue
+		}
+		for _, com := range set.elements {
+			owner := com.Owner()
+			if _, ok := w.getEntityInfo(owner); !ok {
+				continue
+			}
+			if s := w.getComponentSetByIntType(set.meta.it); s != nil {
+				s.Remove(owner)
+			}
+			w.restoreComponent(owner, com)
+		}
+	}
+
+	// 销毁的实体的关系需要清理，ID由diff中的生成器状态决定
+	w.freeEntities()
+	w.idGenerator = generator
+	if frame != w.frame {
+		w.frame = frame
+		w.beginFrameTick()
+	}
+	return nil
+}
+
+func writeEntities(b *binaryWriter, entities []Entity) {
+	b.writeUint32(uint32(len(entities)))
+	for _, entity := range entities {
+		b.writeUint64(uint64(entity))
+	}
+}
+
+func readEntities(b *binaryReader) []Entity {
+	count := int(b.readUint32())
+	var entities []Entity
+	for i := 0; i < count && b.err == nil; i++ {
+		entities = append(entities, Entity(b.readUint64()))
+	}
+	return entities
+}
@@ -0,0 +1,154 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+)
+
+type __diff_Test_S_1 struct {
+	System[__diff_Test_S_1]
+	target Entity
+}
+
+func (s *__diff_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__snapshot_Test_C_1{})
+	return nil
+}
+
+func (s *__diff_Test_S_1) Update(event Event) {
+	if c := GetComponent[__snapshot_Test_C_1](s, s.target); c != nil {
+		c.Field1 = 99
+	}
+}
+
+func TestSyncWorld_Diff(t *testing.T) {
+	src := newSnapshotTestWorld()
+	RegisterSystem[__diff_Test_S_1](src)
+	src.Startup()
+
+	e1 := src.NewEntity()
+	e2 := src.NewEntity()
+	e3 := src.NewEntity()
+	src.Add(e1, &__snapshot_Test_C_1{Field1: 1})
+	src.Add(e2, &__snapshot_Test_C_1{Field1: 2}, &__snapshot_Test_C_2{Value: 2})
+	src.Add(e3, &__snapshot_Test_C_1{Field1: 3})
+	src.Update()
+
+	base := src.frame
+	full := &bytes.Buffer{}
+	if err := src.Snapshot(full); err != nil {
+		t.Fatal(err)
+	}
+	dst := newSnapshotTestWorld()
+	if err := dst.Restore(bytes.NewReader(full.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	sys, _ := src.getSystem(TypeOf[__diff_Test_S_1]())
+	sys.(*__diff_Test_S_1).target = e1
+	src.DestroyEntity(e3)
+	src.Remove(e2, &__snapshot_Test_C_2{})
+	e4 := src.NewEntity()
+	src.Add(e4, &__snapshot_Test_C_1{Field1: 4})
+	src.Update()
+
+	set1 := src.getComponentSet(TypeOf[__snapshot_Test_C_1]()).(*ComponentSet[__snapshot_Test_C_1])
+	if since, _ := src.tickOfFrame(base); !tickBefore(set1.Get(e2).getChangedTick(), since) {
+		t.Errorf("untouched component marked changed at tick %d", set1.Get(e2).getChangedTick())
+	}
+
+	delta := &bytes.Buffer{}
+	if err := src.Diff(base, delta); err != nil {
+		t.Fatal(err)
+	}
+	src.Stop()
+
+	if err := dst.ApplyDiff(bytes.NewReader(delta.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	// 起始帧已不匹配，重复应用需要报错
+	if err := dst.ApplyDiff(bytes.NewReader(delta.Bytes())); err == nil {
+		t.Error("applying the same diff twice should fail")
+	}
+	dst1 := dst.getComponentSet(TypeOf[__snapshot_Test_C_1]()).(*ComponentSet[__snapshot_Test_C_1])
+	dst2 := dst.getComponentSet(TypeOf[__snapshot_Test_C_2]()).(*ComponentSet[__snapshot_Test_C_2])
+	if c := dst1.Get(e1); c == nil || c.Field1 != 99 {
+		t.Errorf("modified component not applied: %+v", c)
+	}
+	if _, ok := dst.getEntityInfo(e3); ok || dst1.Get(e3) != nil {
+		t.Errorf("destroyed entity %d still exists", e3)
+	}
+	info, ok := dst.getEntityInfo(e2)
+	if !ok || dst2.Get(e2) != nil || info.Has(dst2.GetElementMeta().it) {
+		t.Errorf("removed component of entity %d still exists", e2)
+	}
+	if c := dst1.Get(e4); c == nil || c.Field1 != 4 || c.Owner() != e4 {
+		t.Errorf("created entity %d not applied: %+v", e4, c)
+	}
+	if dst1.Len() != 3 {
+		t.Errorf("component count want 3, got %d", dst1.Len())
+	}
+	if dst.frame != src.frame {
+		t.Errorf("frame want %d, got %d", src.frame, dst.frame)
+	}
+}
+
+func TestSyncWorld_DiffReplicaOnly(t *testing.T) {
+	src := newSnapshotTestWorld()
+	src.Startup()
+	src.Add(src.NewEntity(), &__snapshot_Test_C_1{Field1: 1})
+	src.Update()
+
+	base := src.frame
+	full := &bytes.Buffer{}
+	if err := src.Snapshot(full); err != nil {
+		t.Fatal(err)
+	}
+	src.Add(src.NewEntity(), &__snapshot_Test_C_1{Field1: 2})
+	src.Update()
+	delta := &bytes.Buffer{}
+	if err := src.Diff(base, delta); err != nil {
+		t.Fatal(err)
+	}
+	stale := &bytes.Buffer{}
+	if err := src.Diff(base-1, stale); err != nil {
+		t.Fatal(err)
+	}
+	src.Stop()
+
+	dst := newSnapshotTestWorld()
+	if err := dst.Restore(bytes.NewReader(full.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.ApplyDiff(bytes.NewReader(stale.Bytes())); err == nil {
+		t.Error("diff with mismatched base frame should fail")
+	}
+
+	// 本地创建的实体会与增量中的ID冲突
+	local := dst.NewEntity()
+	if err := dst.ApplyDiff(bytes.NewReader(delta.Bytes())); err != ErrDiffLocalEntities {
+		t.Errorf("diff after local entity creation want ErrDiffLocalEntities, got %v", err)
+	}
+	if _, ok := dst.getEntityInfo(local); !ok {
+		t.Errorf("rejected diff modified the world")
+	}
+}
+
+func TestSyncWorld_DiffOutOfHistory(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.HistoryFrames = 2
+	world := NewSyncWorld(config)
+	world.Startup()
+	for i := 0; i < 5; i++ {
+		world.Update()
+	}
+	if err := world.Diff(0, &bytes.Buffer{}); err == nil {
+		t.Error("diff out of history should fail")
+	}
+	if err := world.Diff(world.frame-1, &bytes.Buffer{}); err != nil {
+		t.Error(err)
+	}
+	world.Stop()
+}
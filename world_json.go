@@ -0,0 +1,160 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+type jsonComponent struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type jsonEntity struct {
+	ID         Entity          `json:"id"`
+	Components []jsonComponent `json:"components"`
+}
+
+type jsonWorld struct {
+	Frame    uint64          `json:"frame"`
+	Entities []jsonEntity    `json:"entities"`
+	Free     []jsonComponent `json:"free,omitempty"`
+}
+
+// 导出为可读的JSON，组件仅包含导出字段，按ComponentMetaInfo中的类型名标识
+func (w *ecsWorld) exportJSON(out io.Writer) error {
+	w.checkMainThread()
+
+	doc := jsonWorld{Frame: w.frame, Entities: make([]jsonEntity, 0, w.entities.Len())}
+	var err error
+	w.entities.Range(func(info *EntityInfo) bool {
+		entity := jsonEntity{ID: info.entity, Components: make([]jsonComponent, 0, len(info.compound))}
+		for _, it := range info.compound {
+			set := w.getComponentSetByIntType(it)
+			if set == nil {
+				continue
+			}
+			com := set.GetComponent(info.entity)
+			if com == nil {
+				continue
+			}
+			var c jsonComponent
+			if c, err = encodeJSONComponent(com); err != nil {
+				return false
+			}
+			entity.Components = append(entity.Components, c)
+		}
+		doc.Entities = append(doc.Entities, entity)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		if (*set).GetElementMeta().componentType&ComponentTypeFreeMask == 0 {
+			return true
+		}
+		(*set).Range(func(com IComponent) bool {
+			var c jsonComponent
+			if c, err = encodeJSONComponent(com); err != nil {
+				return false
+			}
+			doc.Free = append(doc.Free, c)
+			return true
+		})
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// 按Merge的方式导入JSON，实体重新分配ID，返回JSON中的ID到新ID的映射，需在帧间调用
+func (w *ecsWorld) importJSON(in io.Reader) (map[Entity]Entity, error) {
+	w.checkMainThread()
+
+	doc := jsonWorld{}
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	known := w.knownComponentTypes()
+
+	snap := &worldSnapshot{}
+	index := map[*ComponentMetaInfo]int{}
+	add := func(owner Entity, c jsonComponent) error {
+		com, err := decodeJSONComponent(known, c)
+		if err != nil {
+			return err
+		}
+		meta := w.getComponentMetaInfoByType(com.Type())
+		isFree := meta.componentType&ComponentTypeFreeMask > 0
+		if isFree != (owner == 0) {
+			return fmt.Errorf("json component %s can not be used here", c.Type)
+		}
+		com.setOwner(owner)
+		i, ok := index[meta]
+		if !ok {
+			i = len(snap.sets)
+			index[meta] = i
+			snap.sets = append(snap.sets, snapshotSet{meta: meta})
+		}
+		snap.sets[i].elements = append(snap.sets[i].elements, com)
+		return nil
+	}
+
+	ids := map[Entity]struct{}{}
+	for _, entity := range doc.Entities {
+		if _, ok := ids[entity.ID]; ok || entity.ID == 0 {
+			return nil, fmt.Errorf("json entity id %d is invalid or repeated", entity.ID)
+		}
+		ids[entity.ID] = struct{}{}
+		snap.entities = append(snap.entities, entity.ID)
+		for _, c := range entity.Components {
+			if err := add(entity.ID, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, c := range doc.Free {
+		if err := add(0, c); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.mergeSnapshot(snap), nil
+}
+
+func encodeJSONComponent(com IComponent) (jsonComponent, error) {
+	value, err := json.Marshal(com)
+	if err != nil {
+		return jsonComponent{}, err
+	}
+	return jsonComponent{Type: componentTypeName(com.Type()), Value: value}, nil
+}
+
+func decodeJSONComponent(known map[string]reflect.Type, c jsonComponent) (IComponent, error) {
+	typ, ok := known[c.Type]
+	if !ok {
+		return nil, fmt.Errorf("json component %s is not registered", c.Type)
+	}
+	com := reflect.New(typ).Interface().(IComponent)
+	if len(c.Value) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(c.Value))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(com); err != nil {
+			return nil, fmt.Errorf("json component %s: %w", c.Type, err)
+		}
+	}
+	return com, nil
+}
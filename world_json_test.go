@@ -0,0 +1,85 @@
+package ecs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSyncWorld_ExportImportJSON(t *testing.T) {
+	src := newSnapshotTestWorld()
+	RegisterComponent[__snapshot_Test_C_4](src)
+	src.Startup()
+	e1 := src.NewEntity()
+	e2 := src.NewEntity()
+	c1 := &__snapshot_Test_C_1{Field1: 1, Field2: 1.5, Field3: [3]int16{1, 2, 3}}
+	c1.Name.Set("hero")
+	src.Add(e1, c1, &__snapshot_Test_C_4{Target: e2})
+	src.Add(e2, &__snapshot_Test_C_2{Value: 9})
+	AddFreeComponent[__snapshot_Test_C_3](src, &__snapshot_Test_C_3{Count: 3})
+	src.Update()
+
+	buf := &bytes.Buffer{}
+	if err := src.ExportJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	src.Stop()
+	if !strings.Contains(buf.String(), `"Name": "hero"`) {
+		t.Errorf("FixedString not exported as plain string:\n%s", buf.String())
+	}
+
+	dst := newSnapshotTestWorld()
+	RegisterComponent[__snapshot_Test_C_4](dst)
+	remap, err := dst.ImportJSON(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set1 := dst.getComponentSet(TypeOf[__snapshot_Test_C_1]()).(*ComponentSet[__snapshot_Test_C_1])
+	set2 := dst.getComponentSet(TypeOf[__snapshot_Test_C_2]()).(*ComponentSet[__snapshot_Test_C_2])
+	set4 := dst.getComponentSet(TypeOf[__snapshot_Test_C_4]()).(*ComponentSet[__snapshot_Test_C_4])
+	if c := set1.Get(remap[e1]); c == nil || c.Field2 != 1.5 || c.Field3[2] != 3 || c.Name.String() != "hero" {
+		t.Errorf("component imported wrong: %+v", c)
+	}
+	if c := set2.Get(remap[e2]); c == nil || c.Value != 9 {
+		t.Errorf("component imported wrong: %+v", c)
+	}
+	if c := set4.Get(remap[e1]); c == nil || c.Target != remap[e2] {
+		t.Errorf("entity field not remapped: %+v", c)
+	}
+	if free := dst.getComponentSet(TypeOf[__snapshot_Test_C_3]()); free.Len() != 1 {
+		t.Errorf("free component count want 1, got %d", free.Len())
+	}
+}
+
+func TestSyncWorld_ImportJSONFixture(t *testing.T) {
+	fixture := `{
+  "entities": [
+    {"id": 1, "components": [{"type": "ecs.__snapshot_Test_C_1", "value": {"Field1": 10, "Name": "npc"}}]},
+    {"id": 2, "components": [{"type": "ecs.__snapshot_Test_C_1"}]}
+  ]
+}`
+	dst := newSnapshotTestWorld()
+	remap, err := dst.ImportJSON(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set1 := dst.getComponentSet(TypeOf[__snapshot_Test_C_1]()).(*ComponentSet[__snapshot_Test_C_1])
+	if c := set1.Get(remap[1]); c == nil || c.Field1 != 10 || c.Name.String() != "npc" {
+		t.Errorf("fixture imported wrong: %+v", c)
+	}
+	if c := set1.Get(remap[2]); c == nil || c.Field1 != 0 {
+		t.Errorf("fixture imported wrong: %+v", c)
+	}
+
+	invalid := []string{
+		`{"entities": [{"id": 1, "components": [{"type": "ecs.unknown"}]}]}`,
+		`{"entities": [{"id": 1, "components": [{"type": "ecs.__snapshot_Test_C_1", "value": {"Field9": 1}}]}]}`,
+		`{"entities": [{"id": 1}, {"id": 1}]}`,
+		`{"entities": [{"id": 1, "components": [{"type": "ecs.__snapshot_Test_C_3"}]}]}`,
+	}
+	for _, data := range invalid {
+		if _, err := dst.ImportJSON(strings.NewReader(data)); err == nil {
+			t.Errorf("import should fail: %s", data)
+		}
+	}
+}
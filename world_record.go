@@ -0,0 +1,428 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+const (
+	recordMagic   = "ECSR"
+	recordVersion = uint16(1)
+)
+
+const (
+	recordKindType uint8 = iota + 1
+	recordKindFrame
+	recordKindNewEntity
+	recordKindDestroyEntity
+	recordKindAdd
+	recordKindRemove
+	recordKindCommand
+	recordKindPauseSet
+	recordKindResumeSet
+	recordKindSetParent
+	recordKindReorderSet
+)
+
+// Command 可序列化的外部输入，通过AsyncWorld.SyncCommand、SyncWorld.Execute提交时会被完整记录，
+// 回放时重新执行。成员需满足IsPureValueType，或实现ICustomSerialize
+type Command interface {
+	Execute(g SyncWrapper) error
+}
+
+type CommandPointer[T any] interface {
+	Command
+	*T
+}
+
+// RegisterCommand 注册命令类型，回放的世界需注册与记录时相同的命令
+func RegisterCommand[T any, TP CommandPointer[T]](world IWorld) {
+	typ := TypeOf[T]()
+	if !isCustomSerialize(typ) && !IsPureValueType(typ) {
+		panic(fmt.Sprintf("command %s must be pure value type or implement ICustomSerialize", typ.String()))
+	}
+	world.base().commands[componentTypeName(typ)] = typ
+}
+
+// 记录世界的外部输入，所有记录在主线程中写入，每帧结束时刷新到输出
+// 格式：头部(magic, version) -> 记录(kind, ...)，组件和命令的类型在第一次出现时写入类型记录
+type recorder struct {
+	b       *binaryWriter
+	types   map[reflect.Type]uint16
+	suspend int
+}
+
+func newRecorder(out io.Writer) *recorder {
+	r := &recorder{
+		b:     newBinaryWriter(out),
+		types: map[reflect.Type]uint16{},
+	}
+	r.b.write([]byte(recordMagic))
+	r.b.writeUint16(recordVersion)
+	return r
+}
+
+func (r *recorder) typeID(typ reflect.Type) uint16 {
+	id, ok := r.types[typ]
+	if ok {
+		return id
+	}
+	id = uint16(len(r.types))
+	r.types[typ] = id
+	r.b.writeUint8(recordKindType)
+	r.b.writeUint16(id)
+	r.b.writeString(componentTypeName(typ))
+	return id
+}
+
+func (r *recorder) header(kind uint8, frame uint64) {
+	r.b.writeUint8(kind)
+	r.b.writeUint64(frame)
+}
+
+func (r *recorder) frame(frame uint64, delta time.Duration) error {
+	r.header(recordKindFrame, frame)
+	r.b.writeUint64(uint64(delta))
+	return r.b.flush()
+}
+
+func (r *recorder) entity(kind uint8, frame uint64, entity Entity) {
+	r.header(kind, frame)
+	r.b.writeUint64(uint64(entity))
+}
+
+func (r *recorder) components(kind uint8, frame uint64, entity Entity, components []IComponent) {
+	ids := make([]uint16, len(components))
+	for i, com := range components {
+		ids[i] = r.typeID(com.Type())
+	}
+	r.header(kind, frame)
+	r.b.writeUint64(uint64(entity))
+	r.b.writeUint16(uint16(len(components)))
+	for i, com := range components {
+		r.b.writeUint16(ids[i])
+		if kind == recordKindAdd {
+			encodeComponent(r.b, com)
+		}
+	}
+}
+
+func (r *recorder) command(frame uint64, cmd Command) {
+	typ := reflect.TypeOf(cmd).Elem()
+	id := r.typeID(typ)
+	r.header(recordKindCommand, frame)
+	r.b.writeUint16(id)
+	encodeCommand(r.b, cmd)
+}
+
+func (r *recorder) setParent(frame uint64, child Entity, parent Entity) {
+	r.header(recordKindSetParent, frame)
+	r.b.writeUint64(uint64(child))
+	r.b.writeUint64(uint64(parent))
+}
+
+func (r *recorder) set(kind uint8, frame uint64, name string) {
+	r.header(kind, frame)
+	r.b.writeString(name)
+}
+
+func (r *recorder) reorderSet(frame uint64, name string, order Order) {
+	r.set(recordKindReorderSet, frame, name)
+	r.b.writeUint32(uint32(order))
+}
+
+func encodeCommand(b *binaryWriter, cmd Command) {
+	if cs, ok := cmd.(ICustomSerialize); ok {
+		b.writeBytes(cs.Serialize())
+		return
+	}
+	v := reflect.ValueOf(cmd)
+	encodePureValue(b, v.Type().Elem(), v.UnsafePointer())
+}
+
+func decodeCommand(b *binaryReader, typ reflect.Type) Command {
+	v := reflect.New(typ)
+	cmd := v.Interface().(Command)
+	if cs, ok := cmd.(ICustomSerialize); ok {
+		data := b.readBytes()
+		if b.err == nil {
+			cs.DeSerialize(data)
+		}
+		return cmd
+	}
+	decodePureValue(b, typ, v.UnsafePointer())
+	return cmd
+}
+
+// 开始记录，只能在Startup前调用
+func (w *ecsWorld) startRecord(out io.Writer) error {
+	if w.getStatus() != WorldStatusInitialized {
+		return errors.New("record must start before world startup")
+	}
+	if w.recorder != nil {
+		return errors.New("world is already recording")
+	}
+	w.recorder = newRecorder(out)
+	return w.recorder.b.err
+}
+
+func (w *ecsWorld) stopRecord() error {
+	if w.recorder == nil {
+		return nil
+	}
+	err := w.recorder.b.flush()
+	w.recorder = nil
+	return err
+}
+
+// 当前需要记录的recorder，执行命令期间命令内的操作不重复记录
+func (w *ecsWorld) recording() *recorder {
+	if w.recorder == nil || w.recorder.suspend > 0 {
+		return nil
+	}
+	return w.recorder
+}
+
+func (w *ecsWorld) recordFrame() {
+	if w.recorder == nil {
+		return
+	}
+	if err := w.recorder.frame(w.frame, w.delta); err != nil {
+		Log.Errorf("record frame %d failed, recording stopped: %v", w.frame, err)
+		w.recorder = nil
+	}
+}
+
+// 记录并执行命令
+func (w *ecsWorld) execute(g SyncWrapper, cmd Command) error {
+	if r := w.recording(); r != nil {
+		r.command(w.frame, cmd)
+		r.suspend++
+		defer func() {
+			r.suspend--
+		}()
+	}
+	return cmd.Execute(g)
+}
+
+// Replayer 使用记录驱动一个新的SyncWorld，世界需注册与记录时相同的系统、组件和命令，并已Startup。
+// 回放中创建的实体ID与记录不同时，后续记录中的实体（包含组件和命令中Entity类型的字段）会被映射到新ID
+type Replayer struct {
+	world   *SyncWorld
+	wrapper SyncWrapper
+	b       *binaryReader
+	types   map[uint16]reflect.Type
+	remap   map[Entity]Entity
+	offsets map[reflect.Type][]uintptr
+	known   map[string]reflect.Type
+}
+
+func NewReplayer(world *SyncWorld, in io.Reader) (*Replayer, error) {
+	b := newBinaryReader(in)
+	magic := make([]byte, len(recordMagic))
+	b.read(magic)
+	if b.err != nil || string(magic) != recordMagic {
+		return nil, ErrSnapshotInvalid
+	}
+	if b.readUint16() != recordVersion {
+		return nil, ErrSnapshotVersion
+	}
+
+	iw := IWorld(world)
+	return &Replayer{
+		world:   world,
+		wrapper: SyncWrapper{world: &iw},
+		b:       b,
+		types:   map[uint16]reflect.Type{},
+		remap:   map[Entity]Entity{},
+		offsets: map[reflect.Type][]uintptr{},
+	}, nil
+}
+
+// Step 应用下一帧的外部输入并执行该帧，记录结束时返回false
+func (r *Replayer) Step() (bool, error) {
+	for {
+		kind, err := r.b.r.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, ErrSnapshotInvalid
+		}
+		if kind == recordKindType {
+			if err := r.readType(); err != nil {
+				return false, err
+			}
+			continue
+		}
+		frame := r.b.readUint64()
+		if r.b.err != nil {
+			return false, r.b.err
+		}
+		if frame != r.world.frame {
+			return false, fmt.Errorf("record frame %d mismatch, world frame %d", frame, r.world.frame)
+		}
+		if kind == recordKindFrame {
+			r.world.delta = time.Duration(r.b.readUint64())
+			if r.b.err != nil {
+				return false, r.b.err
+			}
+			r.world.Update()
+			return true, nil
+		}
+		if err := r.apply(kind); err != nil {
+			return false, err
+		}
+	}
+}
+
+// Run 回放全部记录
+func (r *Replayer) Run() error {
+	for {
+		ok, err := r.Step()
+		if err != nil || !ok {
+			return err
+		}
+	}
+}
+
+func (r *Replayer) readType() error {
+	id := r.b.readUint16()
+	name := r.b.readString()
+	if r.b.err != nil {
+		return r.b.err
+	}
+	typ, ok := r.known[name]
+	if !ok {
+		// 组件可能在回放过程中才注册，未找到时重新收集
+		r.known = map[string]reflect.Type{}
+		for typ := range r.world.componentMeta.types {
+			r.known[componentTypeName(typ)] = typ
+		}
+		for name, typ := range r.world.commands {
+			r.known[name] = typ
+		}
+		if typ, ok = r.known[name]; !ok {
+			return fmt.Errorf("record type %s is not registered", name)
+		}
+	}
+	r.types[id] = typ
+	return nil
+}
+
+func (r *Replayer) typeOf(id uint16) (reflect.Type, error) {
+	typ, ok := r.types[id]
+	if !ok {
+		return nil, ErrSnapshotInvalid
+	}
+	return typ, nil
+}
+
+func (r *Replayer) entity() Entity {
+	entity := Entity(r.b.readUint64())
+	if mapped, ok := r.remap[entity]; ok {
+		return mapped
+	}
+	return entity
+}
+
+func (r *Replayer) entityOffsets(typ reflect.Type, first int) []uintptr {
+	offsets, ok := r.offsets[typ]
+	if !ok {
+		offsets = entityFieldOffsetsFrom(typ, first)
+		r.offsets[typ] = offsets
+	}
+	return offsets
+}
+
+func (r *Replayer) apply(kind uint8) error {
+	b := r.b
+	switch kind {
+	case recordKindNewEntity:
+		recorded := Entity(b.readUint64())
+		if b.err != nil {
+			return b.err
+		}
+		if entity := r.world.NewEntity(); entity != recorded {
+			r.remap[recorded] = entity
+		}
+	case recordKindDestroyEntity:
+		entity := r.entity()
+		if b.err != nil {
+			return b.err
+		}
+		r.world.DestroyEntity(entity)
+	case recordKindAdd, recordKindRemove:
+		entity := r.entity()
+		count := int(b.readUint16())
+		components := make([]IComponent, 0, count)
+		for i := 0; i < count && b.err == nil; i++ {
+			typ, err := r.typeOf(b.readUint16())
+			if err != nil {
+				return err
+			}
+			if kind == recordKindRemove {
+				components = append(components, reflect.New(typ).Interface().(IComponent))
+				continue
+			}
+			com := decodeComponent(b, typ)
+			if len(r.remap) > 0 {
+				remapEntityFields(com, r.entityOffsets(typ, 1), r.remap)
+			}
+			components = append(components, com)
+		}
+		if b.err != nil {
+			return b.err
+		}
+		if kind == recordKindAdd {
+			r.world.Add(entity, components...)
+		} else {
+			r.world.Remove(entity, components...)
+		}
+	case recordKindCommand:
+		typ, err := r.typeOf(b.readUint16())
+		if err != nil {
+			return err
+		}
+		cmd := decodeCommand(b, typ)
+		if b.err != nil {
+			return b.err
+		}
+		if len(r.remap) > 0 {
+			remapEntityAt(reflect.ValueOf(cmd).UnsafePointer(), r.entityOffsets(typ, 0), r.remap)
+		}
+		if err := cmd.Execute(r.wrapper); err != nil {
+			Log.Error(err)
+		}
+	case recordKindSetParent:
+		child := r.entity()
+		parent := r.entity()
+		if b.err != nil {
+			return b.err
+		}
+		r.world.SetParent(child, parent)
+	case recordKindPauseSet, recordKindResumeSet:
+		name := b.readString()
+		if b.err != nil {
+			return b.err
+		}
+		if kind == recordKindPauseSet {
+			r.world.PauseSet(name)
+		} else {
+			r.world.ResumeSet(name)
+		}
+	case recordKindReorderSet:
+		name := b.readString()
+		order := Order(int32(b.readUint32()))
+		if b.err != nil {
+			return b.err
+		}
+		r.world.ReorderSet(name, order)
+	default:
+		return ErrSnapshotInvalid
+	}
+	return nil
+}
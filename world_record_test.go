@@ -0,0 +1,91 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type __record_Test_Cmd_Spawn struct {
+	Value  int
+	Target Entity
+}
+
+func (c *__record_Test_Cmd_Spawn) Execute(g SyncWrapper) error {
+	e := g.NewEntity()
+	g.Add(e, &__world_Test_C_Det_2{Value: c.Value})
+	g.Remove(c.Target, &__world_Test_C_Det_1{})
+	return nil
+}
+
+func newRecordTestWorld() *SyncWorld {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.Deterministic = true
+	config.FrameInterval = time.Millisecond * 5
+	world := NewSyncWorld(config)
+	RegisterSystem[__world_Test_S_Det_1](world)
+	RegisterSystem[__world_Test_S_Det_2](world)
+	RegisterCommand[__record_Test_Cmd_Spawn](world)
+	return world
+}
+
+func TestSyncWorld_RecordReplay(t *testing.T) {
+	var log []string
+	__world_Test_Det_Log = &log
+
+	record := &bytes.Buffer{}
+	src := newRecordTestWorld()
+	if err := src.Record(record); err != nil {
+		t.Fatal(err)
+	}
+	src.Startup()
+
+	var entities []Entity
+	for frame := 0; frame < 10; frame++ {
+		e := src.NewEntity()
+		src.Add(e, &__world_Test_C_Det_1{Value: frame})
+		entities = append(entities, e)
+		if err := src.Execute(&__record_Test_Cmd_Spawn{Value: frame, Target: entities[frame/2]}); err != nil {
+			t.Fatal(err)
+		}
+		if frame%4 == 3 {
+			src.DestroyEntity(entities[frame-1])
+		}
+		src.Update()
+	}
+	if err := src.StopRecord(); err != nil {
+		t.Fatal(err)
+	}
+	want := &bytes.Buffer{}
+	if err := src.Snapshot(want); err != nil {
+		t.Fatal(err)
+	}
+	src.Stop()
+
+	dst := newRecordTestWorld()
+	dst.Startup()
+	replayer, err := NewReplayer(dst, bytes.NewReader(record.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayer.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if dst.GetFrame() != src.GetFrame() {
+		t.Fatalf("replay frame want %d, got %d", src.GetFrame(), dst.GetFrame())
+	}
+	got := &bytes.Buffer{}
+	if err := dst.Snapshot(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Error("replayed world state differs from recorded world")
+	}
+	dst.Stop()
+
+	if _, err := NewReplayer(newRecordTestWorld(), bytes.NewReader([]byte("ECSS"))); err != ErrSnapshotInvalid {
+		t.Errorf("invalid record want ErrSnapshotInvalid, got %v", err)
+	}
+}
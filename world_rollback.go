@@ -0,0 +1,173 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// 帧开始时（该帧的外部输入之前）的世界状态
+type frameState struct {
+	valid        bool
+	frame        uint64
+	tick         uint32
+	delta        time.Duration
+	sets         map[uint16]any
+	entities     entitySetState
+	generator    idGeneratorState
+	events       map[reflect.Type]any
+	systems      map[reflect.Type]systemRunState
+	systemSets   map[string]systemSetState
+	relations    map[reflect.Type]any
+	frameTicks   []frameTick
+	entityLog    []entityRecord
+	journalStart uint64
+}
+
+// 最近WorldConfig.RollbackFrames帧的状态，按帧号循环复用，保存时复用旧状态的内存
+type rollbackBuffer struct {
+	states []*frameState
+}
+
+func newRollbackBuffer(frames int) *rollbackBuffer {
+	r := &rollbackBuffer{states: make([]*frameState, frames)}
+	for i := range r.states {
+		r.states[i] = &frameState{
+			sets:       map[uint16]any{},
+			events:     map[reflect.Type]any{},
+			systems:    map[reflect.Type]systemRunState{},
+			systemSets: map[string]systemSetState{},
+			relations:  map[reflect.Type]any{},
+		}
+	}
+	return r
+}
+
+func (r *rollbackBuffer) slot(frame uint64) *frameState {
+	return r.states[frame%uint64(len(r.states))]
+}
+
+// 保存当前帧开始时的状态，在帧间调用
+func (w *ecsWorld) saveFrameState() {
+	if w.rollback == nil {
+		return
+	}
+	s := w.rollback.slot(w.frame)
+	s.valid = true
+	s.frame = w.frame
+	s.tick = w.tick
+	s.delta = w.delta
+
+	// 组件容器只增不减，按类型id覆盖保存
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		it := (*set).GetElementMeta().it
+		s.sets[it] = (*set).saveState(s.sets[it])
+		return true
+	})
+	w.entities.saveState(&s.entities)
+	w.idGenerator.saveState(&s.generator)
+	for typ, ch := range w.events {
+		s.events[typ] = ch.saveState(s.events[typ])
+	}
+	for k := range s.systems {
+		delete(s.systems, k)
+	}
+	for typ, sys := range w.systemFlow.systems {
+		s.systems[typ] = sys.saveRunState()
+	}
+	w.systemFlow.saveSets(s.systemSets)
+	w.saveRelations(s.relations)
+	s.frameTicks = append(s.frameTicks[:0], w.frameTicks...)
+	s.entityLog = append(s.entityLog[:0], w.entityLog...)
+	s.journalStart = w.journalStart
+}
+
+// 恢复到frame开始时的状态，丢弃当前帧尚未生效的组件操作，此后的帧需要重新模拟
+func (w *ecsWorld) rollbackTo(frame uint64) error {
+	w.checkMainThread()
+	if w.rollback == nil {
+		return fmt.Errorf("rollback is disabled, WorldConfig.RollbackFrames is 0")
+	}
+	if w.recorder != nil {
+		return fmt.Errorf("rollback is not supported while recording")
+	}
+	s := w.rollback.slot(frame)
+	if frame > w.frame || !s.valid || s.frame != frame {
+		return fmt.Errorf("frame %d is out of rollback range, current frame %d", frame, w.frame)
+	}
+	// 系统结构体不在保存范围内，不能恢复此后添加、移除的系统
+	if !w.systemFlow.sameSystems(s.systems) {
+		return fmt.Errorf("systems were added or removed after frame %d, rollback is not supported", frame)
+	}
+
+	w.components.discardTempTasks()
+	w.hierarchyLock.Lock()
+	w.hierarchyOps = nil
+	w.hierarchyLock.Unlock()
+	w.systemFlow.discardPending()
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		if state, ok := s.sets[(*set).GetElementMeta().it]; ok {
+			(*set).loadState(state)
+		} else {
+			(*set).Clear()
+		}
+		return true
+	})
+	w.entities.loadState(&s.entities)
+	w.idGenerator.loadState(&s.generator)
+	for typ, ch := range w.events {
+		ch.loadState(s.events[typ])
+	}
+	for typ, sys := range w.systemFlow.systems {
+		rs := s.systems[typ]
+		// 集合在保存后调整过顺序
+		if rs.order != sys.Order() {
+			w.systemFlow.moveSystem(sys, rs.order)
+		}
+		sys.loadRunState(rs)
+	}
+	w.systemFlow.loadSets(s.systemSets)
+	w.loadRelations(s.relations)
+	w.frameTicks = append(w.frameTicks[:0], s.frameTicks...)
+	w.entityLog = append(w.entityLog[:0], s.entityLog...)
+	w.journalStart = s.journalStart
+	w.frame = s.frame
+	w.tick = s.tick
+	w.delta = s.delta
+
+	// 之后的帧将被重新模拟，旧状态作废
+	for _, state := range w.rollback.states {
+		if state.frame > frame {
+			state.valid = false
+		}
+	}
+	return nil
+}
+
+// 当前注册的系统与保存时一致
+func (p *systemFlow) sameSystems(saved map[reflect.Type]systemRunState) bool {
+	if len(saved) != len(p.systems) {
+		return false
+	}
+	for typ := range p.systems {
+		if _, ok := saved[typ]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// 回滚到frame后重新模拟到回滚前的帧，每帧执行前调用inputs应用该帧（修正后的）外部输入
+func (w *ecsWorld) resimulate(frame uint64, inputs func(frame uint64)) error {
+	target := w.frame
+	if err := w.rollbackTo(frame); err != nil {
+		return err
+	}
+	for w.frame < target {
+		if inputs != nil {
+			inputs(w.frame)
+		}
+		w.update()
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type __rollback_Test_E_1 struct {
+	Value int
+}
+
+type __rollback_Test_S_1 struct {
+	System[__rollback_Test_S_1]
+}
+
+func (s *__rollback_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__world_Test_C_Det_1{})
+	BindEventWriter[__rollback_Test_E_1](si)
+	return nil
+}
+
+func (s *__rollback_Test_S_1) Update(event Event) {
+	iter := GetComponentAll[__world_Test_C_Det_1](s)
+	for c := iter.Begin(); !iter.End(); c = iter.Next() {
+		SendEvent(s, __rollback_Test_E_1{Value: c.Value})
+	}
+}
+
+type __rollback_Test_S_2 struct {
+	System[__rollback_Test_S_2]
+}
+
+func (s *__rollback_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__world_Test_C_Det_2{})
+	BindEventReader[__rollback_Test_E_1](si)
+	return nil
+}
+
+func (s *__rollback_Test_S_2) Update(event Event) {
+	sum := 0
+	for _, e := range ReadEvents[__rollback_Test_E_1](s) {
+		sum += e.Value
+	}
+	iter := GetComponentAll[__world_Test_C_Det_2](s)
+	for c := iter.Begin(); !iter.End(); c = iter.Next() {
+		c.Value += sum
+	}
+}
+
+func newRollbackTestWorld() *SyncWorld {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.Deterministic = true
+	config.RollbackFrames = 8
+	world := NewSyncWorld(config)
+	RegisterSystem[__world_Test_S_Det_1](world)
+	RegisterSystem[__world_Test_S_Det_2](world, Interval(time.Millisecond*50))
+	RegisterSystem[__rollback_Test_S_1](world)
+	RegisterSystem[__rollback_Test_S_2](world)
+	world.Startup()
+	return world
+}
+
+func TestSyncWorld_Rollback(t *testing.T) {
+	var log []string
+	__world_Test_Det_Log = &log
+
+	var entities []Entity
+	inputs := func(world *SyncWorld, value int) func(frame uint64) {
+		return func(frame uint64) {
+			e := world.NewEntity()
+			world.Add(e, &__world_Test_C_Det_1{Value: int(frame) * value}, &__world_Test_C_Det_2{Value: int(frame)})
+			if frame%3 == 2 {
+				world.DestroyEntity(entities[frame/2])
+			}
+			if len(entities) <= int(frame) {
+				entities = append(entities, e)
+			}
+		}
+	}
+	snapshot := func(world *SyncWorld) []byte {
+		buf := &bytes.Buffer{}
+		if err := world.Snapshot(buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	ref := newRollbackTestWorld()
+	correct := inputs(ref, 1)
+	for frame := uint64(0); frame < 10; frame++ {
+		correct(frame)
+		ref.Update()
+	}
+	want := snapshot(ref)
+	ref.Stop()
+
+	entities = nil
+	world := newRollbackTestWorld()
+	correct, wrong := inputs(world, 1), inputs(world, 7)
+	for frame := uint64(0); frame < 10; frame++ {
+		if frame >= 4 {
+			wrong(frame)
+		} else {
+			correct(frame)
+		}
+		world.Update()
+	}
+	if bytes.Equal(want, snapshot(world)) {
+		t.Fatal("mispredicted inputs should produce different state")
+	}
+
+	if err := world.Resimulate(4, correct); err != nil {
+		t.Fatal(err)
+	}
+	if world.GetFrame() != 10 {
+		t.Fatalf("resimulate should return to frame 10, got %d", world.GetFrame())
+	}
+	if !bytes.Equal(want, snapshot(world)) {
+		t.Error("resimulated state differs from reference")
+	}
+
+	if err := world.Rollback(1); err == nil {
+		t.Error("rollback out of range should fail")
+	}
+	if err := world.Rollback(9); err != nil {
+		t.Fatal(err)
+	}
+	if err := world.Rollback(10); err == nil {
+		t.Error("rollback to discarded frame should fail")
+	}
+	world.Stop()
+}
+
+type __rollback_Test_S_Set struct {
+	System[__rollback_Test_S_Set]
+	runs int
+}
+
+func (s *__rollback_Test_S_Set) Update(event Event) {
+	s.runs++
+}
+
+// 回滚恢复集合的暂停状态和顺序
+func TestSyncWorld_RollbackSet(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.RollbackFrames = 8
+	world := NewSyncWorld(config)
+	ConfigureSet(world, "combat", Order(5))
+	RegisterSystem[__rollback_Test_S_Set](world, InSet("combat"))
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__rollback_Test_S_Set]())
+	sys := s.(*__rollback_Test_S_Set)
+	set := world.systemFlow.sets["combat"]
+	groupOrder := func() Order {
+		for _, group := range world.systemFlow.stages[StageUpdate] {
+			if group.has(sys) {
+				return group.order
+			}
+		}
+		return OrderInvalid
+	}
+
+	world.Update()
+	world.Update()
+	world.PauseSet("combat")
+	world.ReorderSet("combat", Order(3))
+	world.Update()
+	world.Update()
+	if !set.paused || sys.runs != 2 || groupOrder() != 3 {
+		t.Fatalf("set should be paused and reordered, paused %v, runs %d, order %d", set.paused, sys.runs, groupOrder())
+	}
+
+	if err := world.Rollback(2); err != nil {
+		t.Fatal(err)
+	}
+	if set.paused || set.order != 5 || sys.Order() != 5 || groupOrder() != 5 {
+		t.Errorf("set state should be restored, paused %v, order %d %d %d", set.paused, set.order, sys.Order(), groupOrder())
+	}
+	world.Update()
+	if sys.runs != 3 {
+		t.Errorf("system should run after rollback, runs %d", sys.runs)
+	}
+
+	// 回滚丢弃尚未生效的集合操作
+	world.PauseSet("combat")
+	if err := world.Rollback(3); err != nil {
+		t.Fatal(err)
+	}
+	world.Update()
+	if set.paused || sys.runs != 4 {
+		t.Errorf("pending set control should be discarded, paused %v, runs %d", set.paused, sys.runs)
+	}
+	world.Stop()
+}
+
+type __rollback_Test_S_Add struct {
+	System[__rollback_Test_S_Add]
+}
+
+func (s *__rollback_Test_S_Add) Update(event Event) {}
+
+// 系统集合变化后不能回滚到变化之前，尚未生效的添加、移除被丢弃
+func TestSyncWorld_RollbackSystemChange(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.RollbackFrames = 8
+	world := NewSyncWorld(config)
+	RegisterSystem[__rollback_Test_S_Set](world)
+	world.Startup()
+
+	world.Update()
+	world.Update()
+	AddSystem[__rollback_Test_S_Add](world)
+	world.Update()
+	if _, ok := world.getSystem(TypeOf[__rollback_Test_S_Add]()); !ok {
+		t.Fatal("system should be added")
+	}
+	if err := world.Rollback(1); err == nil {
+		t.Error("rollback across an added system should fail")
+	}
+	if err := world.Rollback(3); err != nil {
+		t.Fatal(err)
+	}
+
+	RemoveSystem[__rollback_Test_S_Add](world)
+	world.Update()
+	if _, ok := world.getSystem(TypeOf[__rollback_Test_S_Add]()); ok {
+		t.Fatal("system should be removed")
+	}
+	if err := world.Rollback(3); err == nil {
+		t.Error("rollback across a removed system should fail")
+	}
+
+	AddSystem[__rollback_Test_S_Add](world)
+	RemoveSystem[__rollback_Test_S_Set](world)
+	if err := world.Rollback(4); err != nil {
+		t.Fatal(err)
+	}
+	world.Update()
+	if _, ok := world.getSystem(TypeOf[__rollback_Test_S_Add]()); ok {
+		t.Error("pending system add should be discarded")
+	}
+	if _, ok := world.getSystem(TypeOf[__rollback_Test_S_Set]()); !ok {
+		t.Error("pending system remove should be discarded")
+	}
+	world.Stop()
+}
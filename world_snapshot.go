@@ -0,0 +1,352 @@
+package ecs
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+const (
+	snapshotMagic   = "ECSS"
+	snapshotVersion = uint16(1)
+)
+
+type snapshotSet struct {
+	meta     *ComponentMetaInfo
+	elements []IComponent
+}
+
+// 解码后的快照数据，组件类型已映射到当前世界
+type worldSnapshot struct {
+	frame     uint64
+	generator *EntityIDGenerator
+	entities  []Entity
+	sets      []snapshotSet
+}
+
+// 快照格式：头部(magic, version, frame) -> EntityID生成器 -> 组件类型表 -> 实体 -> 组件数据
+func (w *ecsWorld) snapshot(out io.Writer) error {
+	w.checkMainThread()
+
+	b := newBinaryWriter(out)
+	b.write([]byte(snapshotMagic))
+	b.writeUint16(snapshotVersion)
+	b.writeUint64(w.frame)
+
+	encodeIDGenerator(b, w.idGenerator)
+
+	w.writeTypeTable(b)
+
+	b.writeUint32(uint32(w.entities.Len()))
+	w.entities.Range(func(info *EntityInfo) bool {
+		b.writeUint64(uint64(info.entity))
+		return true
+	})
+
+	collections := w.components.getCollections()
+	b.writeUint16(uint16(collections.Len()))
+	collections.Range(func(set *IComponentSet) bool {
+		b.writeUint16((*set).GetElementMeta().it)
+		b.writeUint32(uint32((*set).Len()))
+		(*set).Range(func(com IComponent) bool {
+			b.writeUint64(uint64(com.Owner()))
+			encodeComponent(b, com)
+			return b.err == nil
+		})
+		return b.err == nil
+	})
+
+	return b.flush()
+}
+
+func (w *ecsWorld) decodeSnapshot(in io.Reader) (*worldSnapshot, error) {
+	b := newBinaryReader(in)
+
+	magic := make([]byte, len(snapshotMagic))
+	b.read(magic)
+	if b.err != nil || string(magic) != snapshotMagic {
+		return nil, ErrSnapshotInvalid
+	}
+	if b.readUint16() != snapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+
+	snap := &worldSnapshot{}
+	snap.frame = b.readUint64()
+	snap.generator = decodeIDGenerator(b)
+
+	metas, err := w.readTypeTable(b)
+	if err != nil {
+		return nil, err
+	}
+
+	entityCount := int(b.readUint32())
+	for i := 0; i < entityCount && b.err == nil; i++ {
+		snap.entities = append(snap.entities, Entity(b.readUint64()))
+	}
+
+	setCount := int(b.readUint16())
+	for i := 0; i < setCount && b.err == nil; i++ {
+		set, ok := readSnapshotSet(b, metas)
+		if !ok {
+			return nil, ErrSnapshotInvalid
+		}
+		snap.sets = append(snap.sets, set)
+	}
+
+	if b.err != nil {
+		return nil, b.err
+	}
+	return snap, nil
+}
+
+// 使用快照替换世界当前的全部实体和组件，需在帧间调用
+func (w *ecsWorld) restore(in io.Reader) error {
+	w.checkMainThread()
+
+	snap, err := w.decodeSnapshot(in)
+	if err != nil {
+		return err
+	}
+
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		(*set).Clear()
+		return true
+	})
+	w.entities = NewEntityCollection()
+	w.clearRelations()
+	w.idGenerator = snap.generator
+	w.frame = snap.frame
+	w.resetJournal()
+
+	for _, entity := range snap.entities {
+		w.addEntity(EntityInfo{entity: entity, compound: NewCompound(4)})
+	}
+	for _, set := range snap.sets {
+		for _, com := range set.elements {
+			w.restoreComponent(com.Owner(), com)
+		}
+	}
+	return nil
+}
+
+// 将快照合并到当前世界，快照中的实体使用新分配的ID，组件中Entity类型的字段同步改写，
+// 返回旧ID到新ID的映射，需在帧间调用
+func (w *ecsWorld) merge(in io.Reader) (map[Entity]Entity, error) {
+	w.checkMainThread()
+
+	snap, err := w.decodeSnapshot(in)
+	if err != nil {
+		return nil, err
+	}
+	return w.mergeSnapshot(snap), nil
+}
+
+func (w *ecsWorld) mergeSnapshot(snap *worldSnapshot) map[Entity]Entity {
+	remap := make(map[Entity]Entity, len(snap.entities))
+	for _, entity := range snap.entities {
+		remap[entity] = w.newEntity().Entity()
+	}
+
+	for _, set := range snap.sets {
+		offsets := entityFieldOffsets(set.meta.typ)
+		isFree := set.meta.componentType&ComponentTypeFreeMask > 0
+		for _, com := range set.elements {
+			remapEntityFields(com, offsets, remap)
+			if isFree {
+				w.restoreComponent(0, com)
+				continue
+			}
+			owner, ok := remap[com.Owner()]
+			if !ok {
+				continue
+			}
+			w.restoreComponent(owner, com)
+		}
+	}
+	return remap
+}
+
+// 组件类型表：it -> 组件类型、是否自定义序列化、类型名
+func (w *ecsWorld) writeTypeTable(b *binaryWriter) {
+	infos := w.componentMeta.infos
+	b.writeUint16(uint16(infos.Len()))
+	infos.Range(func(info *ComponentMetaInfo) bool {
+		b.writeUint16(info.it)
+		b.writeUint8(uint8(info.componentType))
+		custom := uint8(0)
+		if isCustomSerialize(info.typ) {
+			custom = 1
+		}
+		b.writeUint8(custom)
+		b.writeString(componentTypeName(info.typ))
+		return true
+	})
+}
+
+// 读取类型表并映射到当前世界的组件类型，返回写入方it到当前世界组件信息的映射
+func (w *ecsWorld) readTypeTable(b *binaryReader) (map[uint16]*ComponentMetaInfo, error) {
+	known := map[string]reflect.Type{}
+	for typ := range w.componentMeta.types {
+		known[componentTypeName(typ)] = typ
+	}
+
+	typeCount := int(b.readUint16())
+	metas := map[uint16]*ComponentMetaInfo{}
+	for i := 0; i < typeCount && b.err == nil; i++ {
+		it := b.readUint16()
+		ct := ComponentType(b.readUint8())
+		custom := b.readUint8() == 1
+		name := b.readString()
+		if b.err != nil {
+			break
+		}
+		typ, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("snapshot component %s is not registered", name)
+		}
+		meta := w.getComponentMetaInfoByType(typ)
+		if meta.componentType != ct || isCustomSerialize(typ) != custom {
+			return nil, fmt.Errorf("snapshot component %s mismatch", name)
+		}
+		metas[it] = meta
+	}
+	return metas, b.err
+}
+
+func writeSnapshotSet(b *binaryWriter, it uint16, elements []IComponent) {
+	b.writeUint16(it)
+	b.writeUint32(uint32(len(elements)))
+	for _, com := range elements {
+		b.writeUint64(uint64(com.Owner()))
+		encodeComponent(b, com)
+	}
+}
+
+func readSnapshotSet(b *binaryReader, metas map[uint16]*ComponentMetaInfo) (snapshotSet, bool) {
+	meta, ok := metas[b.readUint16()]
+	if !ok {
+		return snapshotSet{}, false
+	}
+	set := snapshotSet{meta: meta}
+	count := int(b.readUint32())
+	for j := 0; j < count && b.err == nil; j++ {
+		owner := Entity(b.readUint64())
+		com := decodeComponent(b, meta.typ)
+		com.setOwner(owner)
+		set.elements = append(set.elements, com)
+	}
+	return set, true
+}
+
+var entityType = TypeOf[Entity]()
+
+// 组件中所有Entity类型字段（包含嵌套结构体和数组）的偏移，跳过组件头
+func entityFieldOffsets(typ reflect.Type) []uintptr {
+	return entityFieldOffsetsFrom(typ, 1)
+}
+
+// 结构体从第first个字段开始所有Entity类型字段的偏移
+func entityFieldOffsetsFrom(typ reflect.Type, first int) []uintptr {
+	var offsets []uintptr
+	var walk func(typ reflect.Type, base uintptr)
+	walk = func(typ reflect.Type, base uintptr) {
+		if typ == entityType {
+			offsets = append(offsets, base)
+			return
+		}
+		switch typ.Kind() {
+		case reflect.Array:
+			for i := 0; i < typ.Len(); i++ {
+				walk(typ.Elem(), base+uintptr(i)*typ.Elem().Size())
+			}
+		case reflect.Struct:
+			for i := 0; i < typ.NumField(); i++ {
+				walk(typ.Field(i).Type, base+typ.Field(i).Offset)
+			}
+		}
+	}
+	for i := first; i < typ.NumField(); i++ {
+		walk(typ.Field(i).Type, typ.Field(i).Offset)
+	}
+	return offsets
+}
+
+func remapEntityFields(com IComponent, offsets []uintptr, remap map[Entity]Entity) {
+	remapEntityAt(com.debugAddress(), offsets, remap)
+}
+
+func remapEntityAt(p unsafe.Pointer, offsets []uintptr, remap map[Entity]Entity) {
+	for _, offset := range offsets {
+		field := (*Entity)(unsafe.Add(p, offset))
+		if entity, ok := remap[*field]; ok {
+			*field = entity
+		}
+	}
+}
+
+// 跳过操作队列，直接将组件放入容器
+func (w *ecsWorld) restoreComponent(entity Entity, com IComponent) {
+	meta := w.getOrCreateComponentMetaInfo(com)
+	w.components.checkSet(com)
+	set := w.getComponentSetByIntType(meta.it)
+
+	com.setIntType(meta.it)
+	com.setOwner(entity)
+	com.setAddedTick(w.currentTick())
+	com.addToCollection(meta.componentType, set.pointer())
+
+	if meta.componentType&ComponentTypeFreeMask > 0 {
+		return
+	}
+	if info, ok := w.getEntityInfo(entity); ok {
+		info.addToCompound(meta.it)
+	}
+}
+
+func encodeIDGenerator(b *binaryWriter, g *EntityIDGenerator) {
+	b.writeUint32(uint32(g.free))
+	b.writeUint32(uint32(g.pending))
+	b.writeUint32(uint32(g.len))
+	b.writeUint32(uint32(len(g.ids)))
+	for _, id := range g.ids {
+		b.writeUint32(uint32(id.index))
+		b.writeUint32(uint32(id.reuse))
+	}
+	b.writeUint32(uint32(g.delayCap))
+	b.writeUint32(uint32(g.delayFree))
+	for i := int32(0); i < g.delayFree; i++ {
+		b.writeUint32(uint32(g.removeDelay[i].index))
+		b.writeUint32(uint32(g.removeDelay[i].reuse))
+	}
+}
+
+// 延迟回收队列的容量上限，快照中的容量不可信
+const maxIDDelayCap = 1 << 16
+
+// 生成器的数据来自外部，检查容量与下标，越界的生成器会在之后的NewID、FreeID中panic
+func decodeIDGenerator(b *binaryReader) *EntityIDGenerator {
+	g := &EntityIDGenerator{}
+	g.free = int32(b.readUint32())
+	g.pending = int32(b.readUint32())
+	g.len = int32(b.readUint32())
+	n := int(b.readUint32())
+	for i := 0; i < n && b.err == nil; i++ {
+		g.ids = append(g.ids, RealID{index: int32(b.readUint32()), reuse: int32(b.readUint32())})
+	}
+	g.delayCap = int32(b.readUint32())
+	g.delayFree = int32(b.readUint32())
+	if b.err != nil || g.delayCap <= 0 || g.delayCap > maxIDDelayCap || g.delayFree < 0 || g.delayFree > g.delayCap {
+		b.err = ErrSnapshotInvalid
+		return g
+	}
+	g.removeDelay = make([]RealID, g.delayCap)
+	for i := int32(0); i < g.delayFree; i++ {
+		g.removeDelay[i] = RealID{index: int32(b.readUint32()), reuse: int32(b.readUint32())}
+	}
+	if b.err == nil && !g.valid() {
+		b.err = ErrSnapshotInvalid
+	}
+	return g
+}
@@ -0,0 +1,236 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+type __snapshot_Test_C_1 struct {
+	Component[__snapshot_Test_C_1]
+	Field1 int
+	Field2 float32
+	Field3 [3]int16
+	Name   FixedString[Fixed16]
+}
+
+type __snapshot_Test_C_2 struct {
+	Component[__snapshot_Test_C_2]
+	Value int64
+}
+
+func (c *__snapshot_Test_C_2) Serialize() []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(c.Value))
+	return b
+}
+
+func (c *__snapshot_Test_C_2) DeSerialize(b []byte) {
+	c.Value = int64(binary.BigEndian.Uint64(b))
+}
+
+type __snapshot_Test_C_3 struct {
+	FreeComponent[__snapshot_Test_C_3]
+	Count int
+}
+
+func newSnapshotTestWorld() *SyncWorld {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterComponent[__snapshot_Test_C_1](world)
+	RegisterComponent[__snapshot_Test_C_2](world)
+	RegisterComponent[__snapshot_Test_C_3](world)
+	return world
+}
+
+func TestSyncWorld_SnapshotRestore(t *testing.T) {
+	src := newSnapshotTestWorld()
+	src.Startup()
+
+	var entities []Entity
+	for i := 0; i < 3; i++ {
+		e := src.NewEntity()
+		c1 := &__snapshot_Test_C_1{Field1: i, Field2: float32(i) / 2, Field3: [3]int16{1, 2, int16(i)}}
+		c1.Name.Set("entity")
+		src.Add(e, c1)
+		if i != 1 {
+			src.Add(e, &__snapshot_Test_C_2{Value: int64(i * 100)})
+		}
+		entities = append(entities, e)
+	}
+	AddFreeComponent[__snapshot_Test_C_3](src, &__snapshot_Test_C_3{Count: 7})
+	src.Update()
+
+	buf := &bytes.Buffer{}
+	if err := src.Snapshot(buf); err != nil {
+		t.Fatal(err)
+	}
+	src.Stop()
+
+	dst := newSnapshotTestWorld()
+	if err := dst.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.frame != src.frame {
+		t.Errorf("frame want %d, got %d", src.frame, dst.frame)
+	}
+	set1 := dst.getComponentSet(TypeOf[__snapshot_Test_C_1]()).(*ComponentSet[__snapshot_Test_C_1])
+	set2 := dst.getComponentSet(TypeOf[__snapshot_Test_C_2]()).(*ComponentSet[__snapshot_Test_C_2])
+	for i, e := range entities {
+		info, ok := dst.getEntityInfo(e)
+		if !ok {
+			t.Fatalf("entity %d not restored", e)
+		}
+		c1 := set1.Get(e)
+		if c1 == nil || c1.Field1 != i || c1.Field2 != float32(i)/2 || c1.Field3[2] != int16(i) || c1.Name.String() != "entity" {
+			t.Errorf("component 1 of entity %d restored wrong: %+v", e, c1)
+		}
+		c2 := set2.Get(e)
+		if i == 1 {
+			if c2 != nil || info.Has(set2.GetElementMeta().it) {
+				t.Errorf("entity %d should not have component 2", e)
+			}
+			continue
+		}
+		if c2 == nil || c2.Value != int64(i*100) || c2.Owner() != e {
+			t.Errorf("component 2 of entity %d restored wrong: %+v", e, c2)
+		}
+		if !info.Has(set1.GetElementMeta().it, set2.GetElementMeta().it) {
+			t.Errorf("compound of entity %d restored wrong", e)
+		}
+	}
+	free := dst.getComponentSet(TypeOf[__snapshot_Test_C_3]())
+	if free.Len() != 1 {
+		t.Errorf("free component count want 1, got %d", free.Len())
+	}
+
+	if e := dst.NewEntity(); e == entities[0] || e == entities[1] || e == entities[2] {
+		t.Errorf("entity id %d reused after restore", e)
+	}
+}
+
+func TestSyncWorld_RestoreInvalid(t *testing.T) {
+	dst := newSnapshotTestWorld()
+	if err := dst.Restore(bytes.NewReader([]byte("invalid"))); err == nil {
+		t.Error("restore invalid data should fail")
+	}
+}
+
+type __snapshot_Test_C_4 struct {
+	Component[__snapshot_Test_C_4]
+	Target  Entity
+	Targets [2]Entity
+}
+
+func TestSyncWorld_Merge(t *testing.T) {
+	src := newSnapshotTestWorld()
+	RegisterComponent[__snapshot_Test_C_4](src)
+	src.Startup()
+	e1 := src.NewEntity()
+	e2 := src.NewEntity()
+	src.Add(e1, &__snapshot_Test_C_1{Field1: 1}, &__snapshot_Test_C_4{Target: e2, Targets: [2]Entity{e2, 12345}})
+	src.Add(e2, &__snapshot_Test_C_1{Field1: 2}, &__snapshot_Test_C_4{Target: e1})
+	src.Update()
+
+	buf := &bytes.Buffer{}
+	if err := src.Snapshot(buf); err != nil {
+		t.Fatal(err)
+	}
+	src.Stop()
+
+	dst := newSnapshotTestWorld()
+	RegisterComponent[__snapshot_Test_C_4](dst)
+	existing := []Entity{dst.NewEntity(), dst.NewEntity(), dst.NewEntity()}
+
+	remap, err := dst.Merge(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remap) != 2 {
+		t.Fatalf("remap size want 2, got %d", len(remap))
+	}
+	for _, e := range existing {
+		if remap[e1] == e || remap[e2] == e {
+			t.Fatalf("merged entity collides with existing entity %d", e)
+		}
+	}
+
+	set1 := dst.getComponentSet(TypeOf[__snapshot_Test_C_1]()).(*ComponentSet[__snapshot_Test_C_1])
+	set4 := dst.getComponentSet(TypeOf[__snapshot_Test_C_4]()).(*ComponentSet[__snapshot_Test_C_4])
+	if c := set1.Get(remap[e2]); c == nil || c.Field1 != 2 || c.Owner() != remap[e2] {
+		t.Errorf("merged component wrong: %+v", c)
+	}
+	c4 := set4.Get(remap[e1])
+	if c4 == nil || c4.Target != remap[e2] || c4.Targets[0] != remap[e2] || c4.Targets[1] != 12345 {
+		t.Errorf("entity field not remapped: %+v", c4)
+	}
+	if c4 = set4.Get(remap[e2]); c4 == nil || c4.Target != remap[e1] {
+		t.Errorf("entity field not remapped: %+v", c4)
+	}
+}
+
+// 字节串的长度不可信，超过上限或超出剩余数据时返回ErrSnapshotInvalid
+func TestBinaryReader_ReadBytes(t *testing.T) {
+	data := make([]byte, 4)
+	for _, n := range []uint32{maxReadBytes + 1, 0xFFFFFFF0, 16} {
+		binary.LittleEndian.PutUint32(data, n)
+		b := newBinaryReader(bytes.NewReader(append(data, "short"...)))
+		if p := b.readBytes(); p != nil || b.err != ErrSnapshotInvalid {
+			t.Errorf("length %d want ErrSnapshotInvalid, got %v", n, b.err)
+		}
+	}
+	binary.LittleEndian.PutUint32(data, 5)
+	b := newBinaryReader(bytes.NewReader(append(data, "valid"...)))
+	if s := b.readString(); s != "valid" || b.err != nil {
+		t.Errorf("read string want valid, got %q %v", s, b.err)
+	}
+}
+
+// 生成器的数据截断、容量过大或下标越界时返回ErrSnapshotInvalid
+func TestSyncWorld_DecodeIDGenerator(t *testing.T) {
+	g := NewEntityIDGenerator(4, 3)
+	e := g.NewID()
+	g.NewID()
+	g.FreeID(e)
+	out := &bytes.Buffer{}
+	w := newBinaryWriter(out)
+	encodeIDGenerator(w, g)
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	decode := func(data []byte) error {
+		b := newBinaryReader(bytes.NewReader(data))
+		decodeIDGenerator(b)
+		return b.err
+	}
+	if err := decode(data); err != nil {
+		t.Fatalf("valid generator decode failed, %v", err)
+	}
+
+	patch := func(offset int, v uint32) []byte {
+		p := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(p[offset:], v)
+		return p
+	}
+	delayCap := 16 + 8*len(g.ids)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", data[:len(data)-4]},
+		{"oversized delay cap", patch(delayCap, 0x7FFFFFFF)},
+		{"free out of range", patch(0, uint32(len(g.ids)+1))},
+		{"negative len", patch(8, 0xFFFFFFFF)},
+		{"index out of range", patch(16, 1000)},
+		{"delayed index out of range", patch(delayCap+8, 1000)},
+	}
+	for _, tt := range tests {
+		if err := decode(tt.data); err != ErrSnapshotInvalid {
+			t.Errorf("%s want ErrSnapshotInvalid, got %v", tt.name, err)
+		}
+	}
+}
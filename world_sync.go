@@ -1,6 +1,9 @@
 package ecs
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 type SyncWorld struct {
 	ecsWorld
@@ -26,8 +29,62 @@ func (w *SyncWorld) Stop() {
 	w.stop()
 }
 
+// Record 开始记录世界的外部输入，只能在Startup前调用，记录可以通过Replayer回放
+func (w *SyncWorld) Record(writer io.Writer) error {
+	return w.startRecord(writer)
+}
+
+// StopRecord 停止记录并刷新输出
+func (w *SyncWorld) StopRecord() error {
+	w.checkMainThread()
+	return w.stopRecord()
+}
+
+// Execute 在主线程中立即执行命令，记录时命令会被完整记录
+func (w *SyncWorld) Execute(cmd Command) error {
+	w.checkMainThread()
+	iw := IWorld(w)
+	return w.execute(SyncWrapper{world: &iw}, cmd)
+}
+
+// PauseSet 暂停集合中所有系统的更新阶段，下一帧生效
+func (w *SyncWorld) PauseSet(name string) {
+	w.checkMainThread()
+	if r := w.recording(); r != nil {
+		r.set(recordKindPauseSet, w.frame, name)
+	}
+	w.pauseSet(name)
+}
+
+// ResumeSet 恢复集合中所有系统的更新阶段
+func (w *SyncWorld) ResumeSet(name string) {
+	w.checkMainThread()
+	if r := w.recording(); r != nil {
+		r.set(recordKindResumeSet, w.frame, name)
+	}
+	w.resumeSet(name)
+}
+
+// ReorderSet 将集合中的系统移到order对应的系统组，下一帧生效，与顺序约束矛盾时不调整并记录错误日志
+func (w *SyncWorld) ReorderSet(name string, order Order) {
+	w.checkMainThread()
+	if r := w.recording(); r != nil {
+		r.reorderSet(w.frame, name, order)
+	}
+	w.reorderSet(name, order)
+}
+
+// PoolStats 协程池中各worker的运行统计
+func (w *SyncWorld) PoolStats() []WorkerStats {
+	return w.workPool.Stats()
+}
+
 func (w *SyncWorld) NewEntity() Entity {
-	return w.newEntity().Entity()
+	entity := w.newEntity().Entity()
+	if r := w.recording(); r != nil {
+		r.entity(recordKindNewEntity, w.frame, entity)
+	}
+	return entity
 }
 
 func (w *SyncWorld) DestroyEntity(entity Entity) {
@@ -35,6 +92,9 @@ func (w *SyncWorld) DestroyEntity(entity Entity) {
 	if !ok {
 		return
 	}
+	if r := w.recording(); r != nil {
+		r.entity(recordKindDestroyEntity, w.frame, entity)
+	}
 	info.Destroy(w)
 }
 
@@ -43,6 +103,9 @@ func (w *SyncWorld) Add(entity Entity, components ...IComponent) {
 	if !ok {
 		return
 	}
+	if r := w.recording(); r != nil {
+		r.components(recordKindAdd, w.frame, entity, components)
+	}
 	info.Add(w, components...)
 }
 
@@ -51,9 +114,109 @@ func (w *SyncWorld) Remove(entity Entity, components ...IComponent) {
 	if !ok {
 		return
 	}
+	if r := w.recording(); r != nil {
+		r.components(recordKindRemove, w.frame, entity, components)
+	}
 	info.Remove(w, components...)
 }
 
+// SetParent 将child挂到parent下，下一帧生效，parent为0时解除父子关系
+func (w *SyncWorld) SetParent(child Entity, parent Entity) {
+	if r := w.recording(); r != nil {
+		r.setParent(w.frame, child, parent)
+	}
+	SetParent(w, child, parent)
+}
+
+// RegisterPrefab 注册实体模板，同名模板会被替换
+func (w *SyncWorld) RegisterPrefab(prefab *Prefab) error {
+	return w.registerPrefab(prefab)
+}
+
+// LoadPrefabs 从JSON数组加载实体模板，组件格式与ExportJSON相同，组件类型需已在世界中注册
+func (w *SyncWorld) LoadPrefabs(reader io.Reader) error {
+	return w.loadPrefabs(reader)
+}
+
+// Spawn 按模板创建实体，overrides整体覆盖模板中同类型的组件，组件在下一帧生效
+func (w *SyncWorld) Spawn(prefab string, overrides ...IComponent) (Entity, error) {
+	entities, err := w.SpawnN(prefab, 1, overrides...)
+	if err != nil {
+		return 0, err
+	}
+	return entities[0], nil
+}
+
+// SpawnN 按模板批量创建n个实体，每个实体持有独立的组件副本
+func (w *SyncWorld) SpawnN(prefab string, n int, overrides ...IComponent) ([]Entity, error) {
+	components, err := w.resolvePrefab(prefab)
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+	entities := make([]Entity, n)
+	for i := range entities {
+		entities[i] = w.NewEntity()
+		w.Add(entities[i], instantiatePrefab(components, overrides)...)
+	}
+	return entities, nil
+}
+
+// Snapshot 将世界中的全部实体和组件写入writer，需在帧间调用
+func (w *SyncWorld) Snapshot(writer io.Writer) error {
+	return w.snapshot(writer)
+}
+
+// Restore 使用快照替换世界中的全部实体和组件，快照中的组件类型需已在世界中注册
+func (w *SyncWorld) Restore(reader io.Reader) error {
+	return w.restore(reader)
+}
+
+// Merge 将快照合并到世界中，快照中的实体重新分配ID，组件中Entity类型的字段会被改写为新ID，
+// 返回旧ID到新ID的映射
+func (w *SyncWorld) Merge(reader io.Reader) (map[Entity]Entity, error) {
+	return w.merge(reader)
+}
+
+// ExportJSON 将世界中的实体和组件以JSON格式写入writer，组件仅包含导出字段，FixedString输出为普通字符串
+func (w *SyncWorld) ExportJSON(writer io.Writer) error {
+	return w.exportJSON(writer)
+}
+
+// ImportJSON 按Merge的方式导入ExportJSON格式的数据，JSON中的实体ID仅用于标识，导入后重新分配，
+// 返回JSON中的ID到新ID的映射
+func (w *SyncWorld) ImportJSON(reader io.Reader) (map[Entity]Entity, error) {
+	return w.importJSON(reader)
+}
+
+// Rollback 将世界恢复到frame开始时（该帧的外部输入之前）的状态，frame需在WorldConfig.RollbackFrames保留的范围内，
+// 当前帧尚未生效的外部输入会被丢弃
+func (w *SyncWorld) Rollback(frame uint64) error {
+	return w.rollbackTo(frame)
+}
+
+// Resimulate 回滚到frame后重新模拟到当前帧，每帧执行前调用inputs应用该帧修正后的外部输入
+func (w *SyncWorld) Resimulate(frame uint64, inputs func(frame uint64)) error {
+	return w.resimulate(frame, inputs)
+}
+
+// GetFrame 当前帧号，可作为Diff的起始帧
+func (w *SyncWorld) GetFrame() uint64 {
+	return w.frame
+}
+
+// Diff 将sinceFrame（含）之后创建或销毁的实体、新增、移除或修改的组件写入writer，
+// sinceFrame不能早于WorldConfig.HistoryFrames保留的范围
+func (w *SyncWorld) Diff(sinceFrame uint64, writer io.Writer) error {
+	return w.diff(sinceFrame, writer)
+}
+
+// ApplyDiff 将Diff产生的增量应用到世界中，实体ID保持不变。只能用于仅由快照和增量驱动的副本：
+// 增量的sinceFrame需等于世界当前帧，世界在Restore或上一次ApplyDiff后不能在本地创建、销毁实体，
+// 同一增量重复应用会因起始帧不匹配返回错误
+func (w *SyncWorld) ApplyDiff(reader io.Reader) error {
+	return w.applyDiff(reader)
+}
+
 func (w *SyncWorld) getWorld() IWorld {
 	return w
 }
@@ -1,7 +1,10 @@
 package ecs
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -165,3 +168,166 @@ func Test_ecsWorld_World_launcher(t *testing.T) {
 	wg.Wait()
 	world.Stop()
 }
+
+type __world_Test_C_Det_1 struct {
+	Component[__world_Test_C_Det_1]
+	Value int
+}
+
+func (c *__world_Test_C_Det_1) OnAdd(world IWorld, entity Entity) {
+	*__world_Test_Det_Log = append(*__world_Test_Det_Log, fmt.Sprintf("c1 %d %d", entity, c.Value))
+}
+
+type __world_Test_C_Det_2 struct {
+	Component[__world_Test_C_Det_2]
+	Value int
+}
+
+func (c *__world_Test_C_Det_2) OnAdd(world IWorld, entity Entity) {
+	*__world_Test_Det_Log = append(*__world_Test_Det_Log, fmt.Sprintf("c2 %d %d", entity, c.Value))
+}
+
+var __world_Test_Det_Log *[]string
+
+type __world_Test_S_Det_1 struct {
+	System[__world_Test_S_Det_1]
+}
+
+func (s *__world_Test_S_Det_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__world_Test_C_Det_1{})
+	return nil
+}
+
+func (s *__world_Test_S_Det_1) Update(event Event) {
+	iter := GetComponentAll[__world_Test_C_Det_1](s)
+	for c := iter.Begin(); !iter.End(); c = iter.Next() {
+		c.Value += int(event.Delta / time.Millisecond)
+		if c.Value%3 == 0 {
+			info, _ := s.GetEntityInfo(c.Owner())
+			info.Remove(s.World(), c)
+			info.Add(s.World(), &__world_Test_C_Det_1{Value: c.Value + 1})
+		}
+	}
+}
+
+type __world_Test_S_Det_2 struct {
+	System[__world_Test_S_Det_2]
+}
+
+func (s *__world_Test_S_Det_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__world_Test_C_Det_2{})
+	return nil
+}
+
+func (s *__world_Test_S_Det_2) Update(event Event) {
+	iter := GetComponentAll[__world_Test_C_Det_2](s)
+	for c := iter.Begin(); !iter.End(); c = iter.Next() {
+		c.Value++
+		if c.Value%2 == 0 {
+			info, _ := s.GetEntityInfo(c.Owner())
+			info.Remove(s.World(), c)
+		}
+	}
+}
+
+func Test_ecsWorld_Deterministic(t *testing.T) {
+	run := func() ([]byte, []string) {
+		var log []string
+		__world_Test_Det_Log = &log
+
+		config := NewDefaultWorldConfig()
+		config.Debug = false
+		config.MetaInfoDebugPrint = false
+		config.Deterministic = true
+		config.FrameInterval = time.Millisecond * 5
+		world := NewSyncWorld(config)
+		RegisterSystem[__world_Test_S_Det_1](world)
+		RegisterSystem[__world_Test_S_Det_2](world)
+		world.Startup()
+
+		var entities []Entity
+		for frame := 0; frame < 10; frame++ {
+			for i := 0; i < 5; i++ {
+				e := world.NewEntity()
+				world.Add(e, &__world_Test_C_Det_1{Value: frame*5 + i}, &__world_Test_C_Det_2{Value: i})
+				entities = append(entities, e)
+			}
+			if frame%3 == 2 {
+				world.DestroyEntity(entities[frame])
+			}
+			world.Update()
+		}
+
+		buf := &bytes.Buffer{}
+		if err := world.Snapshot(buf); err != nil {
+			t.Fatal(err)
+		}
+		world.Stop()
+		return buf.Bytes(), log
+	}
+
+	snapshot, log := run()
+	if len(log) == 0 {
+		t.Fatal("hooks not called")
+	}
+	for i := 0; i < 5; i++ {
+		s, l := run()
+		if !bytes.Equal(snapshot, s) {
+			t.Fatalf("run %d: world state differs", i)
+		}
+		if !reflect.DeepEqual(log, l) {
+			t.Fatalf("run %d: hook order differs", i)
+		}
+	}
+}
+
+// 交替添加、移除组件后，相同输入下的迭代顺序一致
+func Test_ecsWorld_DeterministicIterationOrder(t *testing.T) {
+	run := func() []Entity {
+		var log []string
+		__world_Test_Det_Log = &log
+
+		config := NewDefaultWorldConfig()
+		config.Debug = false
+		config.MetaInfoDebugPrint = false
+		config.Deterministic = true
+		world := NewSyncWorld(config)
+		RegisterSystem[__world_Test_S_Det_2](world)
+		world.Startup()
+
+		var entities []Entity
+		for frame := 0; frame < 10; frame++ {
+			for i := 0; i < 4; i++ {
+				e := world.NewEntity()
+				world.Add(e, &__world_Test_C_Det_2{Value: frame*4 + i})
+				entities = append(entities, e)
+				if i%2 == 1 {
+					world.Remove(entities[(frame*7+i)%len(entities)], &__world_Test_C_Det_2{})
+				}
+			}
+			if frame%3 == 1 {
+				world.Add(entities[frame], &__world_Test_C_Det_2{Value: -frame})
+			}
+			world.Update()
+		}
+
+		var order []Entity
+		set := world.getComponentSet(TypeOf[__world_Test_C_Det_2]())
+		set.Range(func(com IComponent) bool {
+			order = append(order, com.Owner())
+			return true
+		})
+		world.Stop()
+		return order
+	}
+
+	order := run()
+	if len(order) == 0 {
+		t.Fatal("no components left")
+	}
+	for i := 0; i < 5; i++ {
+		if o := run(); !reflect.DeepEqual(order, o) {
+			t.Fatalf("run %d: iteration order differs, want %v, got %v", i, order, o)
+		}
+	}
+}